@@ -2,32 +2,88 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gong2sentinel/config"
+	"gong2sentinel/pkg/gong"
 	"gong2sentinel/pkg/gong/auditing"
 	"gong2sentinel/pkg/gong/calls"
+	"gong2sentinel/pkg/gong/crm"
+	"gong2sentinel/pkg/gong/library"
+	"gong2sentinel/pkg/gong/permissions"
+	"gong2sentinel/pkg/gong/settings"
+	"gong2sentinel/pkg/gong/snapshot"
+	"gong2sentinel/pkg/gong/stats"
+	"gong2sentinel/pkg/gong/users"
+	"gong2sentinel/pkg/gong/workspaces"
+	"gong2sentinel/pkg/schema"
 	msSentinel "gong2sentinel/pkg/sentinel"
+	"gong2sentinel/pkg/spool"
+	"gong2sentinel/pkg/state"
+	"gong2sentinel/pkg/tables"
+	"os"
+	"strings"
 	"sync"
+	"time"
 )
 
-func main() {
-	ctx := context.Background()
+const (
+	userSettingsCheckpoints    = "user_settings_history"
+	permissionProfilesSnapshot = "permission_profiles"
+	settingsSnapshot           = "settings"
+	crmIntegrationsSnapshot    = "crm_integrations"
+	userActivityCheckpoint     = "user_activity"
+
+	// driftCheckTimeout bounds the drift check so an unreachable Resource Manager does not hold up a run
+	driftCheckTimeout = time.Second * 20
+)
 
+func main() {
 	logger := logrus.New()
 	logger.SetLevel(logrus.InfoLevel)
 
-	confFile := flag.String("config", "config.yml", "The YAML configuration file.")
-	flag.Parse()
+	// the collector is the default command so existing invocations keep working
+	command, args := "collect", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
 
+	switch command {
+	case "collect":
+		runCollect(logger, args)
+	case "mock-gong":
+		runMockGong(logger, args)
+	case "synthetic":
+		runSynthetic(logger, args)
+	case "webhook":
+		runWebhook(logger, args)
+	case "privacy":
+		runPrivacy(logger, args)
+	case "flush":
+		runFlush(logger, args)
+	case "provision":
+		runProvision(logger, args)
+	case "mock-arm":
+		runMockARM(logger, args)
+	case "schema":
+		runSchema(logger, args)
+	default:
+		logger.WithField("command", command).Fatal("unknown command")
+	}
+}
+
+// loadConfig loads and validates the configuration file and applies its log level.
+func loadConfig(logger *logrus.Logger, confFile string) config.Config {
 	conf := config.Config{}
-	if err := conf.Load(*confFile); err != nil {
-		logger.WithError(err).WithField("config", *confFile).Fatal("failed to load configuration")
+	if err := conf.Load(confFile); err != nil {
+		logger.WithError(err).WithField("config", confFile).Fatal("failed to load configuration")
 	}
 
 	if err := conf.Validate(); err != nil {
-		logger.WithError(err).WithField("config", *confFile).Fatal("invalid configuration")
+		logger.WithError(err).WithField("config", confFile).Fatal("invalid configuration")
 	}
 
 	logrusLevel, err := logrus.ParseLevel(conf.Log.Level)
@@ -38,47 +94,323 @@ func main() {
 	logger.WithField("level", logrusLevel.String()).Info("set log level")
 	logger.SetLevel(logrusLevel)
 
-	collectErrors := make(chan error)
-	collectWG := &sync.WaitGroup{}
+	return conf
+}
 
-	var allGongAuditLogs []map[string]string
-	var allGongUserAccessLogs []map[string]string
+// newSentinel creates the Sentinel client with the configured credentials, upload settings and spool.
+func newSentinel(logger *logrus.Logger, conf config.Config) *msSentinel.Sentinel {
+	azureCloud, err := msSentinel.CloudConfiguration(conf.Microsoft.Cloud, conf.CustomCloud())
+	if err != nil {
+		logger.WithError(err).Fatal("invalid Azure cloud")
+	}
 
-	collectWG.Add(1)
-	go func() {
-		logger.Info("retrieving gong audit logs")
+	sentinel, err := msSentinel.New(logger, msSentinel.Credentials{
+		Type:                conf.Microsoft.Credential,
+		TenantID:            conf.Microsoft.TenantID,
+		ClientID:            conf.Microsoft.AppID,
+		ClientSecret:        conf.Microsoft.SecretKey,
+		SubscriptionID:      conf.Microsoft.SubscriptionID,
+		CertificateFile:     conf.Microsoft.CertificateFile,
+		CertificatePassword: conf.Microsoft.CertificatePassword,
+		FederatedTokenFile:  conf.Microsoft.FederatedTokenFile,
+		Cloud:               azureCloud,
+	})
+	if err != nil {
+		logger.WithError(err).Fatal("could not create MS Sentinel client")
+	}
 
-		defer collectWG.Done()
+	sentinel.SetBatchLimits(conf.Microsoft.DataCollection.MaxBatchBytes, conf.Microsoft.DataCollection.MaxBatchRecords)
+	sentinel.SetUploadWorkers(conf.Microsoft.DataCollection.UploadWorkers)
+	sentinel.SetUploadAttempts(conf.Microsoft.DataCollection.UploadAttempts)
+	sentinel.SetFlushAttempts(conf.Spool.FlushAttempts)
 
-		for logType := range auditing.LogTypeStructMap {
-			auditLogs, audErr := auditing.GetAuditLogsForType(conf.Gong.AccessKey, conf.Gong.AccessSecret, logType, conf.Gong.LookupHours)
-			if audErr != nil {
-				collectErrors <- fmt.Errorf("failed to retrieve Gong Audit Logs for logType %s: %v", logType, audErr)
-				return
-			}
-			allGongAuditLogs = append(allGongAuditLogs, auditLogs...)
+	// without a spool logs are still uploaded, they are only lost when the upload fails
+	sp, err := spool.New(conf.Spool.Directory)
+	if err != nil {
+		logger.WithError(err).Error("could not open spool directory, uploading without the spool")
+		return sentinel
+	}
+	sentinel.SetSpool(sp)
+
+	return sentinel
+}
+
+// collector fetches one kind of Gong data and ships it to its own stream.
+type collector struct {
+	name    string
+	stream  string
+	collect func() ([]map[string]string, error)
+	// commit is called once the records are shipped, e.g. to persist checkpoints
+	commit func() error
+}
+
+// stampRunID adds the collector run ID to every record so all rows of a run can be found in Sentinel.
+func stampRunID(runID string, recordSets ...[]map[string]string) {
+	for _, records := range recordSets {
+		for _, record := range records {
+			_ = schema.Merge(record, tables.Run{RunID: runID})
 		}
-	}()
+	}
+}
 
-	collectWG.Add(1)
-	go func() {
-		logger.Info("retrieving gong user access logs")
+func runCollect(logger *logrus.Logger, args []string) {
+	ctx := context.Background()
 
-		defer collectWG.Done()
+	flags := flag.NewFlagSet("collect", flag.ExitOnError)
+	confFile := flags.String("config", "config.yml", "The YAML configuration file.")
+	_ = flags.Parse(args)
 
-		// Get call IDs
-		callIds, err := calls.GetCallIDs(conf.Gong.AccessKey, conf.Gong.AccessSecret)
-		if err != nil {
-			collectErrors <- fmt.Errorf("failed to retrieve call IDs: %v", err)
+	conf := loadConfig(logger, *confFile)
+
+	runID := uuid.NewString()
+	logger.WithField("run_id", runID).Info("starting collector run")
+
+	// the Sentinel client is set up first so an invalid credential or cloud fails the run before collecting
+	sentinel := newSentinel(logger, conf)
+
+	// drift is only reported, it is checked while collecting and the run ships its logs regardless
+	driftDone := make(chan struct{})
+	go func() {
+		defer close(driftDone)
+
+		if conf.Microsoft.SkipDriftCheck {
 			return
 		}
 
-		allGongUserAccessLogs, err = calls.GetUserAccess(conf.Gong.AccessKey, conf.Gong.AccessSecret, callIds)
-		if err != nil {
-			collectErrors <- fmt.Errorf("failed to retrieve Gong User Access Logs: %v", err)
+		driftCtx, cancel := context.WithTimeout(ctx, driftCheckTimeout)
+		defer cancel()
+
+		_, err := sentinel.CheckTableDrift(driftCtx, logger, provisionSpec(conf))
+		switch {
+		case errors.Is(err, msSentinel.ErrDriftCheckUnavailable):
+			logger.WithError(err).Warn("drift check unavailable, grant read access to the workspace or set skip_drift_check")
+		case err != nil:
+			logger.WithError(err).Warn("could not compare tables with the workspace")
 		}
 	}()
 
+	gongClient := gong.New(conf.Gong.BaseURL, conf.Gong.AccessKey, conf.Gong.AccessSecret)
+	gongClient.SetRateLimit(conf.Gong.RateLimit)
+
+	store, err := state.New(conf.State.Directory)
+	if err != nil {
+		logger.WithError(err).Fatal("could not open state directory")
+	}
+
+	// workspaces are fetched upfront so every collector's records can be enriched with the workspace name,
+	// the permission profiles and library collectors list their workspaces from them
+	allWorkspaces, workspacesErr := workspaces.GetWorkspaces(gongClient)
+	if workspacesErr != nil {
+		logger.WithError(workspacesErr).Warn("could not fetch workspaces, records will not be enriched with workspace names")
+	}
+	workspaceLookup := workspaces.NewLookup(allWorkspaces)
+
+	// users are fetched once and shared by the users and user settings collectors
+	var usersOnce sync.Once
+	var allUsers []users.User
+	var usersErr error
+	getUsers := func() ([]users.User, error) {
+		usersOnce.Do(func() {
+			allUsers, usersErr = users.GetUsers(gongClient)
+		})
+		return allUsers, usersErr
+	}
+
+	var settingsCheckpoints map[string]time.Time
+	var profilesSnapshot permissions.Snapshot
+	var currentSettings snapshot.Snapshot
+	var currentIntegrations snapshot.Snapshot
+	var activityLastDay string
+
+	collectors := []collector{
+		{
+			name:   "audit logs",
+			stream: conf.Microsoft.DataCollection.StreamNameAuditing,
+			collect: func() ([]map[string]string, error) {
+				return auditing.GetAuditLogs(gongClient, auditing.LogTypes(), conf.Gong.LookupHours, conf.Gong.Workers)
+			},
+		},
+		{
+			name:   "user access logs",
+			stream: conf.Microsoft.DataCollection.StreamNameCallUserAccess,
+			collect: func() ([]map[string]string, error) {
+				allCalls, err := calls.GetCalls(gongClient, conf.Gong.LookupHours)
+				if err != nil {
+					return nil, fmt.Errorf("failed to retrieve calls: %v", err)
+				}
+
+				return calls.GetUserAccess(gongClient, allCalls)
+			},
+		},
+		{
+			name:   "users",
+			stream: conf.Microsoft.DataCollection.StreamNameUsers,
+			collect: func() ([]map[string]string, error) {
+				userList, err := getUsers()
+				if err != nil {
+					return nil, err
+				}
+
+				return users.GetUserSnapshot(userList)
+			},
+		},
+		{
+			name:   "user settings history",
+			stream: conf.Microsoft.DataCollection.StreamNameUserSettings,
+			collect: func() ([]map[string]string, error) {
+				checkpoints := map[string]time.Time{}
+				if _, err := store.Load(userSettingsCheckpoints, &checkpoints); err != nil {
+					return nil, err
+				}
+
+				userList, err := getUsers()
+				if err != nil {
+					return nil, err
+				}
+
+				records, next, err := users.GetSettingsChanges(gongClient, userList, checkpoints)
+				settingsCheckpoints = next
+				return records, err
+			},
+			commit: func() error {
+				return store.Save(userSettingsCheckpoints, settingsCheckpoints)
+			},
+		},
+		{
+			name:   "permission profiles",
+			stream: conf.Microsoft.DataCollection.StreamNamePermissionProfiles,
+			collect: func() ([]map[string]string, error) {
+				// without the workspaces every profile would be reported as deleted
+				if workspacesErr != nil {
+					return nil, workspacesErr
+				}
+
+				var previous permissions.Snapshot
+				if _, err := store.Load(permissionProfilesSnapshot, &previous); err != nil {
+					return nil, err
+				}
+
+				records, current, err := permissions.GetProfileChanges(gongClient, allWorkspaces, previous)
+				profilesSnapshot = current
+				return records, err
+			},
+			commit: func() error {
+				return store.Save(permissionProfilesSnapshot, profilesSnapshot)
+			},
+		},
+		{
+			name:   "settings",
+			stream: conf.Microsoft.DataCollection.StreamNameSettings,
+			collect: func() ([]map[string]string, error) {
+				var previous snapshot.Snapshot
+				if _, err := store.Load(settingsSnapshot, &previous); err != nil {
+					return nil, err
+				}
+
+				records, current, err := settings.GetSettingsChanges(gongClient, previous)
+				currentSettings = current
+				return records, err
+			},
+			commit: func() error {
+				return store.Save(settingsSnapshot, currentSettings)
+			},
+		},
+		{
+			name:   "CRM integrations",
+			stream: conf.Microsoft.DataCollection.StreamNameCRMIntegrations,
+			collect: func() ([]map[string]string, error) {
+				var previous snapshot.Snapshot
+				if _, err := store.Load(crmIntegrationsSnapshot, &previous); err != nil {
+					return nil, err
+				}
+
+				records, current, err := crm.GetIntegrationChanges(gongClient, previous)
+				currentIntegrations = current
+				return records, err
+			},
+			commit: func() error {
+				return store.Save(crmIntegrationsSnapshot, currentIntegrations)
+			},
+		},
+		{
+			name:   "calls",
+			stream: conf.Microsoft.DataCollection.StreamNameCalls,
+			collect: func() ([]map[string]string, error) {
+				return calls.GetExtensiveCalls(gongClient, conf.Gong.LookupHours)
+			},
+		},
+		{
+			name:   "library folders",
+			stream: conf.Microsoft.DataCollection.StreamNameLibrary,
+			collect: func() ([]map[string]string, error) {
+				if workspacesErr != nil {
+					return nil, workspacesErr
+				}
+
+				return library.GetFolderInventory(gongClient, allWorkspaces)
+			},
+		},
+		{
+			name:   "user activity statistics",
+			stream: conf.Microsoft.DataCollection.StreamNameUserActivity,
+			collect: func() ([]map[string]string, error) {
+				var lastDay string
+				if _, err := store.Load(userActivityCheckpoint, &lastDay); err != nil {
+					return nil, err
+				}
+
+				records, next, err := stats.GetDailyActivity(gongClient, conf.Gong.LookupHours, lastDay)
+				activityLastDay = next
+				return records, err
+			},
+			commit: func() error {
+				return store.Save(userActivityCheckpoint, activityLastDay)
+			},
+		},
+		{
+			name:   "workspaces",
+			stream: conf.Microsoft.DataCollection.StreamNameWorkspaces,
+			collect: func() ([]map[string]string, error) {
+				if workspacesErr != nil {
+					return nil, workspacesErr
+				}
+
+				return workspaces.ToRecords(allWorkspaces), nil
+			},
+		},
+	}
+
+	// optional collectors are only enabled when their stream is configured
+	var enabled []collector
+	for _, c := range collectors {
+		if c.stream == "" {
+			logger.WithField("collector", c.name).Debug("skipping collector without stream")
+			continue
+		}
+		enabled = append(enabled, c)
+	}
+
+	results := make([][]map[string]string, len(enabled))
+
+	collectErrors := make(chan error, len(enabled))
+	collectWG := &sync.WaitGroup{}
+
+	for i, c := range enabled {
+		collectWG.Add(1)
+		go func(i int, c collector) {
+			logger.Infof("retrieving gong %s", c.name)
+
+			defer collectWG.Done()
+
+			records, err := c.collect()
+			if err != nil {
+				collectErrors <- fmt.Errorf("failed to retrieve Gong %s: %v", c.name, err)
+				return
+			}
+			results[i] = records
+		}(i, c)
+	}
+
 	collectDone := make(chan struct{})
 	go func() {
 		collectWG.Wait()
@@ -93,51 +425,46 @@ func main() {
 		logger.Info("finished retrieving logs")
 	}
 
-	sentinel, err := msSentinel.New(logger, msSentinel.Credentials{
-		TenantID:       conf.Microsoft.TenantID,
-		ClientID:       conf.Microsoft.AppID,
-		ClientSecret:   conf.Microsoft.SecretKey,
-		SubscriptionID: conf.Microsoft.SubscriptionID,
-	})
-	if err != nil {
-		logger.WithError(err).Fatal("could not create MS Sentinel client")
+	stampRunID(runID, results...)
+
+	for _, records := range results {
+		workspaceLookup.Enrich(records)
 	}
 
-	ingestErrors := make(chan error)
+	ingestErrors := make(chan error, len(enabled))
 	ingestWG := &sync.WaitGroup{}
 
-	ingestWG.Add(1)
-	go func() {
-		logger.WithField("total", len(allGongAuditLogs)).Info("shipping off Gong audit logs to Sentinel")
-
-		if err := sentinel.SendLogs(ctx, logger,
-			conf.Microsoft.DataCollection.Endpoint,
-			conf.Microsoft.DataCollection.RuleID,
-			conf.Microsoft.DataCollection.StreamNameAuditing,
-			allGongAuditLogs); err != nil {
-			ingestErrors <- fmt.Errorf("could not ship audit logs to sentinel: %v", err)
-		}
+	for i, c := range enabled {
+		ingestWG.Add(1)
+		go func(records []map[string]string, c collector) {
+			defer ingestWG.Done()
 
-		logger.WithField("total", len(allGongAuditLogs)).Info("successfully sent Gong Auditing logs to sentinel")
-		ingestWG.Done()
-	}()
+			logger.WithField("total", len(records)).Infof("shipping off Gong %s to Sentinel", c.name)
 
-	ingestWG.Add(1)
-	go func() {
-		logger.WithField("total", len(allGongUserAccessLogs)).Info("shipping off Gong user access logs to Sentinel")
+			if err := sentinel.SendLogs(ctx, logger,
+				conf.Microsoft.DataCollection.Endpoint,
+				conf.Microsoft.DataCollection.RuleID,
+				c.stream,
+				records); err != nil {
+				// rejected logs will never be accepted, so the rest of the run is committed
+				var rejectedErr *msSentinel.RejectedError
+				if !errors.As(err, &rejectedErr) {
+					ingestErrors <- fmt.Errorf("could not ship %s to sentinel: %v", c.name, err)
+					return
+				}
 
-		if err := sentinel.SendLogs(ctx, logger,
-			conf.Microsoft.DataCollection.Endpoint,
-			conf.Microsoft.DataCollection.RuleID,
-			conf.Microsoft.DataCollection.StreamNameCallUserAccess,
-			allGongUserAccessLogs); err != nil {
-			ingestErrors <- fmt.Errorf("could not ship access logs to sentinel: %v", err)
+				logger.WithError(err).Warnf("some Gong %s were rejected by sentinel", c.name)
+			}
 
-		}
+			logger.WithField("total", len(records)).Infof("successfully sent Gong %s to sentinel", c.name)
 
-		logger.WithField("total", len(allGongUserAccessLogs)).Info("successfully sent Gong User Access logs to sentinel")
-		ingestWG.Done()
-	}()
+			if c.commit != nil {
+				if err := c.commit(); err != nil {
+					ingestErrors <- fmt.Errorf("could not commit %s: %v", c.name, err)
+				}
+			}
+		}(results[i], c)
+	}
 
 	ingestDone := make(chan struct{})
 	go func() {
@@ -152,4 +479,6 @@ func main() {
 	case <-ingestDone:
 		logger.Info("finished ingesting logs")
 	}
+
+	<-driftDone
 }
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/sirupsen/logrus"
+)
+
+func runFlush(logger *logrus.Logger, args []string) {
+	ctx := context.Background()
+
+	flags := flag.NewFlagSet("flush", flag.ExitOnError)
+	confFile := flags.String("config", "config.yml", "The YAML configuration file.")
+	_ = flags.Parse(args)
+
+	conf := loadConfig(logger, *confFile)
+
+	sentinel := newSentinel(logger, conf)
+
+	if err := sentinel.Flush(ctx, logger); err != nil {
+		logger.WithError(err).Fatal("failed to flush spool")
+	}
+}
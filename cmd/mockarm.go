@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/sentinel/mock"
+	"net/http"
+)
+
+func runMockARM(logger *logrus.Logger, args []string) {
+	flags := flag.NewFlagSet("mock-arm", flag.ExitOnError)
+	addr := flags.String("addr", "127.0.0.1:8081", "The address to listen on.")
+	subscriptionID := flags.String("subscription-id", "00000000-0000-0000-0000-000000000000", "The subscription of the workspace.")
+	resourceGroup := flags.String("resource-group", "gong2sentinel", "The resource group of the workspace.")
+	workspaceName := flags.String("workspace-name", "gong2sentinel", "The name of the Log Analytics workspace.")
+	location := flags.String("location", "westeurope", "The location of the workspace.")
+	logLevel := flags.String("log-level", "info", "The log level.")
+	_ = flags.Parse(args)
+
+	if level, err := logrus.ParseLevel(*logLevel); err != nil {
+		logger.WithError(err).Error("invalid log level provided")
+	} else {
+		logger.SetLevel(level)
+	}
+
+	server := mock.New(logger, mock.Options{
+		SubscriptionID: *subscriptionID,
+		ResourceGroup:  *resourceGroup,
+		WorkspaceName:  *workspaceName,
+		Location:       *location,
+	})
+
+	logger.WithField("addr", *addr).Info("serving mock azure resource manager")
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		logger.WithError(err).Fatal("mock azure resource manager stopped")
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/gong/mock"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func runMockGong(logger *logrus.Logger, args []string) {
+	flags := flag.NewFlagSet("mock-gong", flag.ExitOnError)
+	addr := flags.String("addr", "127.0.0.1:8080", "The address to listen on.")
+	accessKey := flags.String("access-key", "mock", "The access key clients must authenticate with.")
+	accessSecret := flags.String("access-secret", "mock", "The access secret clients must authenticate with.")
+	users := flags.Int("users", 25, "The number of users referenced by generated data.")
+	numCalls := flags.Int("calls", 200, "The number of calls to serve.")
+	logEntries := flags.Int("log-entries", 500, "The number of log entries to generate per log type.")
+	pageSize := flags.Int("page-size", 100, "The maximum number of records per page.")
+	window := flags.Duration("window", time.Hour*24, "How far back generated events are spread.")
+	rateLimitRate := flags.Float64("rate-limit-rate", 0, "Probability (0-1) of answering a request with 429.")
+	serverErrorRate := flags.Float64("server-error-rate", 0, "Probability (0-1) of answering a request with 500.")
+	emptyLogTypes := flags.String("empty-log-types", "", "Comma separated log types that always return no records.")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "The seed used to generate data.")
+	logLevel := flags.String("log-level", "info", "The log level.")
+	_ = flags.Parse(args)
+
+	if level, err := logrus.ParseLevel(*logLevel); err != nil {
+		logger.WithError(err).Error("invalid log level provided")
+	} else {
+		logger.SetLevel(level)
+	}
+
+	opts := mock.Options{
+		AccessKey:       *accessKey,
+		AccessSecret:    *accessSecret,
+		Users:           *users,
+		Calls:           *numCalls,
+		LogEntries:      *logEntries,
+		PageSize:        *pageSize,
+		Window:          *window,
+		RateLimitRate:   *rateLimitRate,
+		ServerErrorRate: *serverErrorRate,
+		Seed:            *seed,
+	}
+	if *emptyLogTypes != "" {
+		opts.EmptyLogTypes = strings.Split(*emptyLogTypes, ",")
+	}
+
+	server := mock.New(logger, opts)
+
+	logger.WithField("addr", *addr).WithField("seed", *seed).Info("serving mock gong api")
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		logger.WithError(err).Fatal("mock gong api stopped")
+	}
+}
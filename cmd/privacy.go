@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/privacy"
+	"os"
+	"time"
+)
+
+func runPrivacy(logger *logrus.Logger, args []string) {
+	if len(args) == 0 || args[0] != "lookup" {
+		logger.Fatal("usage: privacy lookup [flags] <email address or phone number>")
+	}
+
+	runPrivacyLookup(logger, args[1:])
+}
+
+func runPrivacyLookup(logger *logrus.Logger, args []string) {
+	ctx := context.Background()
+
+	flags := flag.NewFlagSet("privacy lookup", flag.ExitOnError)
+	confFile := flags.String("config", "config.yml", "The YAML configuration file.")
+	asJSON := flags.Bool("json", false, "Print the full Gong response as JSON instead of a summary.")
+	logToSentinel := flags.Bool("log-to-sentinel", false, "Log the lookup itself to the configured privacy lookups stream.")
+	requester := flags.String("requester", os.Getenv("USER"), "Who performed the lookup, logged for accountability.")
+	reason := flags.String("reason", "", "Why the lookup was performed, e.g. the DSAR ticket number.")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		logger.Fatal("exactly one email address or phone number must be provided")
+	}
+
+	conf := loadConfig(logger, *confFile)
+
+	streamName := conf.Microsoft.DataCollection.StreamNamePrivacyLookups
+	if *logToSentinel && streamName == "" {
+		logger.Fatal("no privacy lookups stream configured, set microsoft.dcr.stream_name_privacy_lookups")
+	}
+	if *logToSentinel && conf.Privacy.HashKey == "" {
+		logger.Fatal("no privacy hash key configured, set privacy.hash_key to log lookups")
+	}
+
+	gongClient := gong.New(conf.Gong.BaseURL, conf.Gong.AccessKey, conf.Gong.AccessSecret)
+	gongClient.SetRateLimit(conf.Gong.RateLimit)
+
+	// the lookup is logged before it is performed so failed and aborted lookups leave a trace as well
+	var logLookup func(record map[string]string)
+	if *logToSentinel {
+		sentinel := newSentinel(logger, conf)
+
+		logLookup = func(record map[string]string) {
+			if err := sentinel.SendLogs(ctx, logger,
+				conf.Microsoft.DataCollection.Endpoint,
+				conf.Microsoft.DataCollection.RuleID,
+				streamName,
+				[]map[string]string{record}); err != nil {
+				logger.WithError(err).Fatal("could not log privacy lookup to sentinel")
+			}
+
+			logger.WithFields(logrus.Fields{"stream": streamName, "outcome": record["outcome"]}).
+				Info("logged privacy lookup to sentinel")
+		}
+	}
+
+	audit := privacy.Audit{
+		LookupID:   uuid.NewString(),
+		Requester:  *requester,
+		Reason:     *reason,
+		Identifier: flags.Arg(0),
+		HashKey:    []byte(conf.Privacy.HashKey),
+	}
+
+	if logLookup != nil {
+		logLookup(audit.AttemptRecord(time.Now()))
+	}
+
+	result, err := privacy.Lookup(gongClient, flags.Arg(0))
+	if err != nil {
+		if logLookup != nil {
+			logLookup(audit.FailureRecord(err, time.Now()))
+		}
+		logger.WithError(err).Fatal("failed to look up data subject")
+	}
+
+	if logLookup != nil {
+		logLookup(audit.SuccessRecord(result, time.Now()))
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			logger.WithError(err).Fatal("failed to print lookup result")
+		}
+	} else {
+		printPrivacySummary(result)
+	}
+}
+
+func printPrivacySummary(result *privacy.Result) {
+	fmt.Printf("Gong data for %s %s (request %s)\n\n", result.IdentifierType, result.Identifier, result.RequestID)
+
+	if len(result.MatchingPhoneNumbers) > 0 {
+		fmt.Printf("Matching phone numbers: %v\n", result.MatchingPhoneNumbers)
+	}
+	if len(result.EmailAddresses) > 0 {
+		fmt.Printf("Email addresses:        %v\n", result.EmailAddresses)
+	}
+
+	fmt.Printf("Calls (%d)\n", len(result.Calls))
+	for _, call := range result.Calls {
+		fmt.Printf("  %-24s %s\n", call.ID, call.Status)
+	}
+
+	fmt.Printf("Emails (%d)\n", len(result.Emails))
+	for _, email := range result.Emails {
+		fmt.Printf("  %-24s %-22s from %s (%s)\n", email.ID, email.SentTime, email.From, email.Mailbox)
+	}
+
+	fmt.Printf("Meetings (%d)\n", len(result.Meetings))
+	for _, meeting := range result.Meetings {
+		fmt.Printf("  %s\n", meeting.ID)
+	}
+
+	fmt.Printf("CRM objects (%d)\n", len(result.CustomerData))
+	fmt.Printf("Engagement events (%d)\n", len(result.CustomerEngagement))
+}
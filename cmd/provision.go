@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/config"
+	msSentinel "gong2sentinel/pkg/sentinel"
+	"gong2sentinel/pkg/tables"
+)
+
+func runProvision(logger *logrus.Logger, args []string) {
+	ctx := context.Background()
+
+	flags := flag.NewFlagSet("provision", flag.ExitOnError)
+	confFile := flags.String("config", "config.yml", "The YAML configuration file.")
+	dryRun := flags.Bool("dry-run", false, "Print the changes without applying them.")
+	standIn := flags.String("stand-in", "", "Provision against a local ARM stand-in such as mock-arm, e.g. http://127.0.0.1:8081.")
+	_ = flags.Parse(args)
+
+	conf := loadConfig(logger, *confFile)
+
+	sentinel := newSentinel(logger, conf)
+	if *standIn != "" {
+		sentinel.UseARMStandIn(*standIn)
+	}
+
+	changes, err := sentinel.PlanProvision(ctx, provisionSpec(conf))
+	if err != nil {
+		logger.WithError(err).Fatal("failed to compare resources with Azure")
+	}
+
+	pending := printChanges(changes)
+
+	if *dryRun {
+		return
+	}
+
+	if pending == 0 {
+		logger.Info("all resources are up to date")
+	}
+
+	provisioned, err := sentinel.ApplyProvision(ctx, logger, changes)
+	if err != nil {
+		logger.WithError(err).Fatal("failed to provision resources")
+	}
+
+	logger.WithField("endpoint", provisioned.IngestionEndpoint).WithField("rule_id", provisioned.RuleImmutableID).
+		Info("provisioned resources")
+
+	if provisioned.IngestionEndpoint != conf.Microsoft.DataCollection.Endpoint ||
+		provisioned.RuleImmutableID != conf.Microsoft.DataCollection.RuleID {
+		fmt.Printf("\nset microsoft.dcr.endpoint to %s and microsoft.dcr.rule_id to %s\n",
+			provisioned.IngestionEndpoint, provisioned.RuleImmutableID)
+	}
+}
+
+// provisionSpec describes the resources of the configured streams.
+func provisionSpec(conf config.Config) msSentinel.ProvisionSpec {
+	return msSentinel.ProvisionSpec{
+		SubscriptionID: conf.Microsoft.SubscriptionID,
+		ResourceGroup:  conf.Microsoft.ResourceGroup,
+		WorkspaceName:  conf.Microsoft.WorkspaceName,
+		EndpointName:   conf.Microsoft.DataCollection.EndpointName,
+		RuleName:       conf.Microsoft.DataCollection.RuleName,
+		Streams:        provisionStreams(conf),
+	}
+}
+
+// provisionStreams returns the configured streams with the table their collector writes to and its lifecycle.
+func provisionStreams(conf config.Config) []msSentinel.Stream {
+	dcr := conf.Microsoft.DataCollection
+
+	candidates := []msSentinel.Stream{
+		{Name: dcr.StreamNameAuditing, Table: tables.AuditLogs},
+		{Name: dcr.StreamNameCallUserAccess, Table: tables.CallUserAccess},
+		{Name: dcr.StreamNameUsers, Table: tables.Users},
+		{Name: dcr.StreamNameUserSettings, Table: tables.UserSettings},
+		{Name: dcr.StreamNamePermissionProfiles, Table: tables.PermissionProfiles},
+		{Name: dcr.StreamNameWorkspaces, Table: tables.Workspaces},
+		{Name: dcr.StreamNameSettings, Table: tables.Settings},
+		{Name: dcr.StreamNameCRMIntegrations, Table: tables.CRMIntegrations},
+		{Name: dcr.StreamNameCalls, Table: tables.Calls},
+		{Name: dcr.StreamNameLibrary, Table: tables.LibraryFolders},
+		{Name: dcr.StreamNameUserActivity, Table: tables.UserActivity},
+		{Name: dcr.StreamNameSynthetic, Table: tables.Synthetic},
+		{Name: dcr.StreamNameWebhook, Table: tables.Webhook},
+		{Name: dcr.StreamNamePrivacyLookups, Table: tables.PrivacyLookups},
+	}
+
+	// optional streams are only provisioned when configured, like their collectors
+	var streams []msSentinel.Stream
+	for _, stream := range candidates {
+		if stream.Name != "" {
+			stream.Lifecycle = conf.TableLifecycle(stream.Table.Name)
+			streams = append(streams, stream)
+		}
+	}
+
+	return streams
+}
+
+// printChanges prints the diff preview and returns the number of resources that will change.
+func printChanges(changes []msSentinel.Change) int {
+	symbols := map[string]string{
+		msSentinel.ActionCreate: "+",
+		msSentinel.ActionUpdate: "~",
+		msSentinel.ActionNone:   "=",
+	}
+
+	var pending int
+	for _, change := range changes {
+		fmt.Printf("%s %s %s (%s)\n", symbols[change.Action], change.Kind, change.Name, change.Action)
+		for _, field := range change.Fields {
+			fmt.Printf("    %s\n", field)
+		}
+
+		if change.Action != msSentinel.ActionNone {
+			pending++
+		}
+	}
+
+	fmt.Printf("\n%d of %d resources to create or update\n", pending, len(changes))
+
+	return pending
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/schema"
+	"gong2sentinel/pkg/tables"
+	"os"
+)
+
+const (
+	schemaFormatTable     = "table"
+	schemaFormatStream    = "stream"
+	schemaFormatTransform = "transform"
+	schemaFormatAll       = "all"
+)
+
+// tableSchema is the schema of a custom table as set in its properties.schema.
+type tableSchema struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Columns     []schema.Column `json:"columns"`
+}
+
+// streamDeclaration is a stream of the DCR streamDeclarations.
+type streamDeclaration struct {
+	Columns []schema.Column `json:"columns"`
+}
+
+// dataFlow is the DCR data flow of a stream, without its destinations as those depend on the deployment.
+type dataFlow struct {
+	Streams      []string `json:"streams"`
+	TransformKql string   `json:"transformKql"`
+	OutputStream string   `json:"outputStream"`
+}
+
+func runSchema(logger *logrus.Logger, args []string) {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	tableName := flags.String("table", "", "Only print the given table, e.g. GongAuditLogs. All tables by default.")
+	format := flags.String("format", schemaFormatAll,
+		"What to print: table for the table schemas, stream for the DCR stream declarations, transform for the DCR data flows or all.")
+	_ = flags.Parse(args)
+
+	selected := tables.All
+	if *tableName != "" {
+		table, ok := tables.Find(*tableName)
+		if !ok {
+			logger.WithField("table", *tableName).Fatal("unknown table")
+		}
+		selected = []schema.Table{table}
+	}
+
+	var schemas []tableSchema
+	declarations := map[string]streamDeclaration{}
+	var dataFlows []dataFlow
+
+	for _, table := range selected {
+		schemas = append(schemas, tableSchema{
+			Name:        table.TableName(),
+			Description: table.Description,
+			Columns:     table.Columns,
+		})
+		declarations[table.StreamName()] = streamDeclaration{Columns: table.StreamColumns()}
+		dataFlows = append(dataFlows, dataFlow{
+			Streams:      []string{table.StreamName()},
+			TransformKql: table.TransformKQL(),
+			OutputStream: table.OutputStream(),
+		})
+	}
+
+	var output interface{}
+	switch *format {
+	case schemaFormatTable:
+		output = schemas
+	case schemaFormatStream:
+		output = declarations
+	case schemaFormatTransform:
+		output = dataFlows
+	case schemaFormatAll:
+		output = map[string]interface{}{
+			"tables":             schemas,
+			"streamDeclarations": declarations,
+			"dataFlows":          dataFlows,
+		}
+	default:
+		logger.WithField("format", *format).Fatal("unknown format, should be table, stream, transform or all")
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		logger.WithError(err).Fatal("failed to print schema")
+	}
+}
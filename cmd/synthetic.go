@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/synthetic"
+	"os"
+	"strings"
+	"time"
+)
+
+func runSynthetic(logger *logrus.Logger, args []string) {
+	ctx := context.Background()
+
+	defaults := synthetic.DefaultOptions()
+
+	flags := flag.NewFlagSet("synthetic", flag.ExitOnError)
+	confFile := flags.String("config", "config.yml", "The YAML configuration file.")
+	scenarioNames := flags.String("scenario", "all", "Comma separated scenarios to generate, or 'all'.")
+	list := flags.Bool("list", false, "List the available scenarios and exit.")
+	dryRun := flags.Bool("dry-run", false, "Print the generated records instead of shipping them.")
+	stream := flags.String("stream", "", "The DCR stream to ship to, overrides the configured synthetic stream.")
+	externalIP := flags.String("external-ip", defaults.ExternalIP, "The IP used by external viewers.")
+	numCalls := flags.Int("calls", defaults.Calls, "The number of calls played in the mass playback scenario.")
+	_ = flags.Parse(args)
+
+	if *list {
+		for _, scenario := range synthetic.Scenarios() {
+			fmt.Printf("%-30s %s\n", scenario.Name, scenario.Description)
+		}
+		return
+	}
+
+	if *numCalls < 1 {
+		logger.WithField("calls", *numCalls).Fatal("the number of calls must be at least 1")
+	}
+
+	opts := defaults
+	opts.ExternalIP = *externalIP
+	opts.Calls = *numCalls
+
+	var names []string
+	if *scenarioNames == "all" {
+		for _, scenario := range synthetic.Scenarios() {
+			names = append(names, scenario.Name)
+		}
+	} else {
+		names = strings.Split(*scenarioNames, ",")
+	}
+
+	now := time.Now()
+
+	var records []map[string]string
+	for _, name := range names {
+		scenarioRecords, err := synthetic.Generate(strings.TrimSpace(name), opts, now)
+		if err != nil {
+			logger.WithError(err).Fatal("failed to generate synthetic events")
+		}
+
+		logger.WithField("scenario", name).WithField("total", len(scenarioRecords)).Info("generated synthetic events")
+		records = append(records, scenarioRecords...)
+	}
+
+	if *dryRun {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(records); err != nil {
+			logger.WithError(err).Fatal("failed to print synthetic events")
+		}
+		return
+	}
+
+	conf := loadConfig(logger, *confFile)
+
+	streamName := conf.Microsoft.DataCollection.StreamNameSynthetic
+	if *stream != "" {
+		streamName = *stream
+	}
+	if streamName == "" {
+		logger.Fatal("no synthetic stream configured, set microsoft.dcr.stream_name_synthetic or pass -stream")
+	}
+
+	sentinel := newSentinel(logger, conf)
+
+	logger.WithField("stream", streamName).WithField("run_id", opts.RunID).WithField("total", len(records)).
+		Info("shipping off synthetic events to Sentinel")
+
+	if err := sentinel.SendLogs(ctx, logger,
+		conf.Microsoft.DataCollection.Endpoint,
+		conf.Microsoft.DataCollection.RuleID,
+		streamName,
+		records); err != nil {
+		logger.WithError(err).Fatal("could not ship synthetic events to sentinel")
+	}
+
+	logger.WithField("run_id", opts.RunID).Info("successfully sent synthetic events to sentinel")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/gong/webhook"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	webhookShutdownTimeout = time.Second * 30
+)
+
+func runWebhook(logger *logrus.Logger, args []string) {
+	flags := flag.NewFlagSet("webhook", flag.ExitOnError)
+	confFile := flags.String("config", "config.yml", "The YAML configuration file.")
+	_ = flags.Parse(args)
+
+	conf := loadConfig(logger, *confFile)
+
+	if conf.Gong.Webhook.URL == "" || conf.Gong.Webhook.PublicKeyFile == "" {
+		logger.Fatal("gong.webhook.url and gong.webhook.public_key_file are required to receive webhooks")
+	}
+
+	if conf.Microsoft.DataCollection.StreamNameWebhook == "" {
+		logger.Fatal("no webhook stream configured, set microsoft.dcr.stream_name_webhook")
+	}
+
+	pemData, err := os.ReadFile(conf.Gong.Webhook.PublicKeyFile)
+	if err != nil {
+		logger.WithError(err).Fatal("could not read gong webhook public key")
+	}
+
+	publicKey, err := webhook.ParsePublicKey(pemData)
+	if err != nil {
+		logger.WithError(err).Fatal("invalid gong webhook public key")
+	}
+
+	sentinel := newSentinel(logger, conf)
+
+	handler := webhook.New(logger, publicKey, conf.Gong.Webhook.URL, func(ctx context.Context, records []map[string]string) error {
+		return sentinel.SendLogs(ctx, logger,
+			conf.Microsoft.DataCollection.Endpoint,
+			conf.Microsoft.DataCollection.RuleID,
+			conf.Microsoft.DataCollection.StreamNameWebhook,
+			records)
+	})
+
+	server := &http.Server{
+		Addr:              conf.Gong.Webhook.ListenAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: time.Second * 10,
+	}
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		logger.Info("shutting down webhook listener")
+
+		ctx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(ctx); err != nil {
+			logger.WithError(err).Error("could not gracefully shut down webhook listener")
+		}
+	}()
+
+	logger.WithField("addr", server.Addr).Info("listening for gong webhooks")
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.WithError(err).Fatal("webhook listener stopped")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	validator "github.com/asaskevich/govalidator"
 	"github.com/kelseyhightower/envconfig"
+	"gong2sentinel/pkg/sentinel"
+	"gong2sentinel/pkg/tables"
 	"gopkg.in/yaml.v3"
 	"os"
 )
@@ -11,6 +13,16 @@ import (
 const (
 	defaultLogLevel      = "DEBUG"
 	defaultRetentionDays = 90
+	defaultGongBaseURL   = "https://api.gong.io"
+	defaultWebhookListen = ":8080"
+	defaultGongWorkers   = 3
+	defaultGongRateLimit = 3
+	defaultStateDir      = "state"
+	defaultSpoolDir      = "spool"
+	defaultResourceName  = "gong2sentinel"
+
+	// unlimitedRateLimit disables the Gong rate limit, zero falls back to the default
+	unlimitedRateLimit = -1
 )
 
 type Config struct {
@@ -18,41 +30,172 @@ type Config struct {
 		Level string `yaml:"level" env:"LOG_LEVEL" valid:"optional"`
 	} `json:"log"`
 
+	State struct {
+		Directory string `yaml:"directory" env:"STATE_DIRECTORY" valid:"optional"`
+	} `yaml:"state"`
+
+	Spool struct {
+		Directory string `yaml:"directory" env:"SPOOL_DIRECTORY" valid:"optional"`
+		// FlushAttempts is the number of flushes an entry may fail before it is parked
+		FlushAttempts int `yaml:"flush_attempts" env:"SPOOL_FLUSH_ATTEMPTS" valid:"optional"`
+	} `yaml:"spool"`
+
 	Microsoft struct {
-		AppID          string `yaml:"app_id" env:"MS_APP_ID" valid:"minstringlength(3)"`
-		SecretKey      string `yaml:"secret_key" env:"MS_SECRET_KEY" valid:"minstringlength(3)"`
-		TenantID       string `yaml:"tenant_id" env:"MS_TENANT_ID" valid:"minstringlength(3)"`
+		// Cloud selects the Azure cloud, see sentinel.CloudNames
+		Cloud       string `yaml:"cloud" env:"MS_CLOUD" valid:"optional"`
+		CustomCloud struct {
+			AuthorityHost     string `yaml:"authority_host" env:"MS_CLOUD_AUTHORITY_HOST" valid:"optional"`
+			IngestionAudience string `yaml:"ingestion_audience" env:"MS_CLOUD_INGESTION_AUDIENCE" valid:"optional"`
+			ARMEndpoint       string `yaml:"arm_endpoint" env:"MS_CLOUD_ARM_ENDPOINT" valid:"optional"`
+			ARMAudience       string `yaml:"arm_audience" env:"MS_CLOUD_ARM_AUDIENCE" valid:"optional"`
+		} `yaml:"custom_cloud"`
+
+		// Credential selects how to authenticate to Azure, see sentinel.CredentialTypes
+		Credential     string `yaml:"credential" env:"MS_CREDENTIAL" valid:"optional"`
+		AppID          string `yaml:"app_id" env:"MS_APP_ID" valid:"optional"`
+		SecretKey      string `yaml:"secret_key" env:"MS_SECRET_KEY" valid:"optional"`
+		TenantID       string `yaml:"tenant_id" env:"MS_TENANT_ID" valid:"optional"`
 		SubscriptionID string `yaml:"subscription_id" env:"MS_SUB_ID" valid:"minstringlength(3)"`
 
+		CertificateFile     string `yaml:"certificate_file" env:"MS_CERTIFICATE_FILE" valid:"optional"`
+		CertificatePassword string `yaml:"certificate_password" env:"MS_CERTIFICATE_PASSWORD" valid:"optional"`
+		FederatedTokenFile  string `yaml:"federated_token_file" env:"MS_FEDERATED_TOKEN_FILE" valid:"optional"`
+
 		DataCollection struct {
-			Endpoint                 string `yaml:"endpoint" env:"MS_DCR_ENDPOINT" valid:"minstringlength(3)"`
-			RuleID                   string `yaml:"rule_id" env:"MS_DCR_RULE" valid:"minstringlength(3)"`
-			StreamNameAuditing       string `yaml:"stream_name_auditing" env:"MS_DCR_STREAM_AUDITING" valid:"minstringlength(3)"`
-			StreamNameCallUserAccess string `yaml:"stream_name_user_access" env:"MS_DCR_STREAM_CALL_USER_ACCESS" valid:"minstringlength(3)"`
+			Endpoint                     string `yaml:"endpoint" env:"MS_DCR_ENDPOINT" valid:"minstringlength(3)"`
+			RuleID                       string `yaml:"rule_id" env:"MS_DCR_RULE" valid:"minstringlength(3)"`
+			EndpointName                 string `yaml:"endpoint_name" env:"MS_DCR_ENDPOINT_NAME" valid:"optional"`
+			RuleName                     string `yaml:"rule_name" env:"MS_DCR_RULE_NAME" valid:"optional"`
+			MaxBatchBytes                int    `yaml:"max_batch_bytes" env:"MS_DCR_MAX_BATCH_BYTES" valid:"optional"`
+			MaxBatchRecords              int    `yaml:"max_batch_records" env:"MS_DCR_MAX_BATCH_RECORDS" valid:"optional"`
+			UploadWorkers                int    `yaml:"upload_workers" env:"MS_DCR_UPLOAD_WORKERS" valid:"optional"`
+			UploadAttempts               int    `yaml:"upload_attempts" env:"MS_DCR_UPLOAD_ATTEMPTS" valid:"optional"`
+			StreamNameAuditing           string `yaml:"stream_name_auditing" env:"MS_DCR_STREAM_AUDITING" valid:"minstringlength(3)"`
+			StreamNameCallUserAccess     string `yaml:"stream_name_user_access" env:"MS_DCR_STREAM_CALL_USER_ACCESS" valid:"minstringlength(3)"`
+			StreamNameUsers              string `yaml:"stream_name_users" env:"MS_DCR_STREAM_USERS" valid:"optional"`
+			StreamNameUserSettings       string `yaml:"stream_name_user_settings" env:"MS_DCR_STREAM_USER_SETTINGS" valid:"optional"`
+			StreamNamePermissionProfiles string `yaml:"stream_name_permission_profiles" env:"MS_DCR_STREAM_PERMISSION_PROFILES" valid:"optional"`
+			StreamNameWorkspaces         string `yaml:"stream_name_workspaces" env:"MS_DCR_STREAM_WORKSPACES" valid:"optional"`
+			StreamNameSettings           string `yaml:"stream_name_settings" env:"MS_DCR_STREAM_SETTINGS" valid:"optional"`
+			StreamNameCRMIntegrations    string `yaml:"stream_name_crm_integrations" env:"MS_DCR_STREAM_CRM_INTEGRATIONS" valid:"optional"`
+			StreamNameCalls              string `yaml:"stream_name_calls" env:"MS_DCR_STREAM_CALLS" valid:"optional"`
+			StreamNameLibrary            string `yaml:"stream_name_library" env:"MS_DCR_STREAM_LIBRARY" valid:"optional"`
+			StreamNameUserActivity       string `yaml:"stream_name_user_activity" env:"MS_DCR_STREAM_USER_ACTIVITY" valid:"optional"`
+			StreamNameSynthetic          string `yaml:"stream_name_synthetic" env:"MS_DCR_STREAM_SYNTHETIC" valid:"optional"`
+			StreamNameWebhook            string `yaml:"stream_name_webhook" env:"MS_DCR_STREAM_WEBHOOK" valid:"optional"`
+			StreamNamePrivacyLookups     string `yaml:"stream_name_privacy_lookups" env:"MS_DCR_STREAM_PRIVACY_LOOKUPS" valid:"optional"`
 		} `yaml:"dcr"`
 
 		ResourceGroup string `yaml:"resource_group" env:"MS_RSG_ID" valid:"minstringlength(3)"`
 		WorkspaceName string `yaml:"workspace_name" env:"MS_WS_NAME" valid:"minstringlength(3)"`
 
-		RetentionDays uint32 `yaml:"retention_days" env:"MS_RETENTION_DAYS" valid:"optional"`
+		// RetentionDays, TotalRetentionDays and TablePlan apply to every table unless overridden in Tables
+		RetentionDays      uint32 `yaml:"retention_days" env:"MS_RETENTION_DAYS" valid:"optional"`
+		TotalRetentionDays uint32 `yaml:"total_retention_days" env:"MS_TOTAL_RETENTION_DAYS" valid:"optional"`
+		TablePlan          string `yaml:"table_plan" env:"MS_TABLE_PLAN" valid:"optional"`
+		// Tables is keyed by table name without the _CL suffix, e.g. GongAuditLogs
+		Tables map[string]TableSettings `yaml:"tables" ignored:"true"`
+		// SkipDriftCheck disables comparing the tables with the workspace on every collector run
+		SkipDriftCheck bool `yaml:"skip_drift_check" env:"MS_SKIP_DRIFT_CHECK" valid:"optional"`
 	} `yaml:"microsoft"`
 
+	Privacy struct {
+		// HashKey keys the HMAC of the identifiers in the privacy lookups stream, keep it secret
+		HashKey string `yaml:"hash_key" env:"PRIVACY_HASH_KEY" valid:"optional"`
+	} `yaml:"privacy"`
+
 	Gong struct {
+		BaseURL      string `yaml:"base_url" env:"GONG_BASE_URL" valid:"optional"`
 		AccessKey    string `yaml:"access_key" env:"GONG_ACCESS_KEY" valid:"minstringlength(3)"`
 		AccessSecret string `yaml:"access_secret" env:"GONG_ACCESS_SECRET" valid:"minstringlength(3)"`
 		LookupHours  int64  `yaml:"lookup_hours" env:"GONG_LOOKUP_HOURS" valid:"numeric"`
+		Workers      int    `yaml:"workers" env:"GONG_WORKERS" valid:"optional"`
+		RateLimit    int    `yaml:"rate_limit" env:"GONG_RATE_LIMIT" valid:"optional"`
+
+		Webhook struct {
+			ListenAddr    string `yaml:"listen_addr" env:"GONG_WEBHOOK_LISTEN_ADDR" valid:"optional"`
+			URL           string `yaml:"url" env:"GONG_WEBHOOK_URL" valid:"optional"`
+			PublicKeyFile string `yaml:"public_key_file" env:"GONG_WEBHOOK_PUBLIC_KEY_FILE" valid:"optional"`
+		} `yaml:"webhook"`
 	} `yaml:"gong"`
 }
 
+// TableSettings overrides the retention and plan of a single table, zero values keep the defaults.
+type TableSettings struct {
+	RetentionDays      uint32 `yaml:"retention_days"`
+	TotalRetentionDays uint32 `yaml:"total_retention_days"`
+	Plan               string `yaml:"plan"`
+}
+
 func (c *Config) Validate() error {
 	if c.Log.Level == "" {
 		c.Log.Level = defaultLogLevel
 	}
 
+	if c.State.Directory == "" {
+		c.State.Directory = defaultStateDir
+	}
+
+	if c.Microsoft.Cloud == "" {
+		c.Microsoft.Cloud = sentinel.CloudPublic
+	}
+
+	if c.Microsoft.Credential == "" {
+		c.Microsoft.Credential = sentinel.CredentialClientSecret
+	}
+
+	if c.Spool.Directory == "" {
+		c.Spool.Directory = defaultSpoolDir
+	}
+
+	if c.Spool.FlushAttempts == 0 {
+		c.Spool.FlushAttempts = sentinel.DefaultFlushAttempts
+	}
+
 	if c.Microsoft.RetentionDays == 0 {
 		c.Microsoft.RetentionDays = defaultRetentionDays
 	}
 
+	if c.Microsoft.TablePlan == "" {
+		c.Microsoft.TablePlan = sentinel.PlanAnalytics
+	}
+
+	if c.Microsoft.DataCollection.EndpointName == "" {
+		c.Microsoft.DataCollection.EndpointName = defaultResourceName
+	}
+
+	if c.Microsoft.DataCollection.RuleName == "" {
+		c.Microsoft.DataCollection.RuleName = defaultResourceName
+	}
+
+	if c.Microsoft.DataCollection.MaxBatchBytes == 0 {
+		c.Microsoft.DataCollection.MaxBatchBytes = sentinel.DefaultBatchBytes
+	}
+
+	if c.Microsoft.DataCollection.UploadWorkers == 0 {
+		c.Microsoft.DataCollection.UploadWorkers = sentinel.DefaultUploadWorkers
+	}
+
+	if c.Microsoft.DataCollection.UploadAttempts == 0 {
+		c.Microsoft.DataCollection.UploadAttempts = sentinel.DefaultUploadAttempts
+	}
+
+	if c.Gong.BaseURL == "" {
+		c.Gong.BaseURL = defaultGongBaseURL
+	}
+
+	if c.Gong.Workers == 0 {
+		c.Gong.Workers = defaultGongWorkers
+	}
+
+	if c.Gong.RateLimit == 0 {
+		c.Gong.RateLimit = defaultGongRateLimit
+	}
+
+	if c.Gong.Webhook.ListenAddr == "" {
+		c.Gong.Webhook.ListenAddr = defaultWebhookListen
+	}
+
 	if valid, err := validator.ValidateStruct(c); !valid || err != nil {
 		return fmt.Errorf("invalid configuration: %v", err)
 	}
@@ -61,6 +204,125 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid lookup hours, should be positive number: %d", c.Gong.LookupHours)
 	}
 
+	if c.Gong.Workers < 0 {
+		return fmt.Errorf("invalid gong workers, should be positive number: %d", c.Gong.Workers)
+	}
+
+	if c.Gong.RateLimit < unlimitedRateLimit {
+		return fmt.Errorf("invalid gong rate limit, should be positive number or %d to disable it: %d", unlimitedRateLimit, c.Gong.RateLimit)
+	}
+
+	if err := c.validateCredential(); err != nil {
+		return err
+	}
+
+	if _, err := sentinel.CloudConfiguration(c.Microsoft.Cloud, c.CustomCloud()); err != nil {
+		return fmt.Errorf("invalid cloud: %v", err)
+	}
+
+	if err := c.validateTables(); err != nil {
+		return err
+	}
+
+	if c.Microsoft.DataCollection.MaxBatchBytes < 0 || c.Microsoft.DataCollection.MaxBatchBytes > sentinel.MaxRequestBytes {
+		return fmt.Errorf("invalid max batch bytes, should be at most %d or 0 for the default: %d",
+			sentinel.MaxRequestBytes, c.Microsoft.DataCollection.MaxBatchBytes)
+	}
+
+	if c.Microsoft.DataCollection.UploadWorkers < 0 {
+		return fmt.Errorf("invalid upload workers, should be positive number: %d", c.Microsoft.DataCollection.UploadWorkers)
+	}
+
+	if c.Microsoft.DataCollection.UploadAttempts < 0 {
+		return fmt.Errorf("invalid upload attempts, should be positive number: %d", c.Microsoft.DataCollection.UploadAttempts)
+	}
+
+	if c.Microsoft.DataCollection.MaxBatchRecords < 0 {
+		return fmt.Errorf("invalid max batch records, should be positive number: %d", c.Microsoft.DataCollection.MaxBatchRecords)
+	}
+
+	return nil
+}
+
+// CustomCloud returns the endpoints of the custom cloud.
+func (c *Config) CustomCloud() sentinel.CustomCloud {
+	return sentinel.CustomCloud{
+		AuthorityHost:     c.Microsoft.CustomCloud.AuthorityHost,
+		IngestionAudience: c.Microsoft.CustomCloud.IngestionAudience,
+		ARMEndpoint:       c.Microsoft.CustomCloud.ARMEndpoint,
+		ARMAudience:       c.Microsoft.CustomCloud.ARMAudience,
+	}
+}
+
+// TableLifecycle returns the retention and plan of a table, keyed by its name without the _CL suffix.
+func (c *Config) TableLifecycle(name string) sentinel.TableLifecycle {
+	lifecycle := sentinel.TableLifecycle{
+		Plan:               c.Microsoft.TablePlan,
+		RetentionDays:      int(c.Microsoft.RetentionDays),
+		TotalRetentionDays: int(c.Microsoft.TotalRetentionDays),
+	}
+
+	if settings, ok := c.Microsoft.Tables[name]; ok {
+		if settings.Plan != "" {
+			lifecycle.Plan = settings.Plan
+		}
+		if settings.RetentionDays != 0 {
+			lifecycle.RetentionDays = int(settings.RetentionDays)
+		}
+		if settings.TotalRetentionDays != 0 {
+			lifecycle.TotalRetentionDays = int(settings.TotalRetentionDays)
+		}
+	}
+
+	return lifecycle
+}
+
+// validateTables checks the retention and plan of every table and that overrides refer to existing tables.
+func (c *Config) validateTables() error {
+	known := map[string]bool{}
+	for _, table := range tables.All {
+		known[table.Name] = true
+
+		if err := c.TableLifecycle(table.Name).Validate(); err != nil {
+			return fmt.Errorf("invalid lifecycle for table %s: %v", table.Name, err)
+		}
+	}
+
+	for name := range c.Microsoft.Tables {
+		if !known[name] {
+			return fmt.Errorf("invalid configuration: unknown table '%s' in microsoft.tables", name)
+		}
+	}
+
+	return nil
+}
+
+// validateCredential checks that the fields needed by the selected Azure credential are set.
+func (c *Config) validateCredential() error {
+	required := map[string]string{}
+
+	switch c.Microsoft.Credential {
+	case sentinel.CredentialClientSecret:
+		required["tenant_id"] = c.Microsoft.TenantID
+		required["app_id"] = c.Microsoft.AppID
+		required["secret_key"] = c.Microsoft.SecretKey
+	case sentinel.CredentialClientCertificate:
+		required["tenant_id"] = c.Microsoft.TenantID
+		required["app_id"] = c.Microsoft.AppID
+		required["certificate_file"] = c.Microsoft.CertificateFile
+	case sentinel.CredentialManagedIdentity, sentinel.CredentialWorkloadIdentity,
+		sentinel.CredentialAzureCLI, sentinel.CredentialDefault:
+		// these pick up their identity from the environment
+	default:
+		return fmt.Errorf("invalid credential '%s', should be one of %v", c.Microsoft.Credential, sentinel.CredentialTypes)
+	}
+
+	for _, field := range []string{"tenant_id", "app_id", "secret_key", "certificate_file"} {
+		if value, ok := required[field]; ok && len(value) < 3 {
+			return fmt.Errorf("invalid configuration: microsoft.%s is required for the %s credential", field, c.Microsoft.Credential)
+		}
+	}
+
 	return nil
 }
 
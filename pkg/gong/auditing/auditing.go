@@ -1,95 +1,140 @@
 package auditing
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
-	"io"
-	"net/http"
-	"strings"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/schema"
+	"net/url"
 	"time"
 )
 
 const (
 	iso8601Format = "2006-01-02T15:04:05Z"
+	logsPath      = "/v2/logs"
+
+	noRecordsMessage = "No log records found corresponding to the provided log type and time range"
 )
 
-func GetAuditLogsForType(accessKey string, secretKey string, logType string, lookupHours int64) ([]map[string]string, error) {
-	now := time.Now().UTC()
-	fromDateTime := now.Add(-time.Duration(lookupHours) * time.Hour).Format(iso8601Format)
+// LookupWindow returns the time range covered by a run with the given lookup hours.
+// Other collectors use it so their data lines up with the audit logs of the same run.
+func LookupWindow(now time.Time, lookupHours int64) (time.Time, time.Time) {
+	now = now.UTC()
+	return now.Add(-time.Duration(lookupHours) * time.Hour), now
+}
 
-	url := fmt.Sprintf("https://api.gong.io/v2/logs?logType=%s&fromDateTime=%s", logType, fromDateTime)
-	logrus.Infof("Fetching URL for logType %s: %s", logType, url)
+func GetAuditLogsForType(client *gong.Client, logType string, lookupHours int64) ([]map[string]string, error) {
+	from, _ := LookupWindow(time.Now(), lookupHours)
 
-	client := &http.Client{
-		Timeout: time.Second * 50,
-	}
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %v", err)
-	}
+	query := url.Values{}
+	query.Set("logType", logType)
+	query.Set("fromDateTime", from.Format(iso8601Format))
 
-	req.SetBasicAuth(accessKey, secretKey)
+	mappedLogs := []map[string]string{}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		logrus.Errorf("Failed to send HTTP request for logType %s: %v", logType, err)
-		return nil, fmt.Errorf("failed to send HTTP request: %v", err)
-	}
-	defer resp.Body.Close()
+	for page := 0; ; page++ {
+		endpoint := client.URL(logsPath, query)
+		logrus.Infof("Fetching URL for logType %s: %s", logType, endpoint)
 
-	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %v", err)
+		var response struct {
+			RequestID string `json:"requestId"`
+			Records   struct {
+				Cursor string `json:"cursor"`
+			} `json:"records"`
+			LogEntries []map[string]interface{} `json:"logEntries"`
 		}
+		if err := client.Get(logsPath, query, &response); err != nil {
+			var apiErr *gong.APIError
+			if errors.As(err, &apiErr) && apiErr.Contains(noRecordsMessage) {
+				logrus.Warnf("No log records found for logType %s", logType)
+				break
+			}
 
-		var errorResponse struct {
-			RequestID string   `json:"requestId"`
-			Errors    []string `json:"errors"`
-		}
-		if err := json.Unmarshal(body, &errorResponse); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal error response: %v", err)
+			logrus.Errorf("Failed to fetch audit logs for logType %s: %v", logType, err)
+			return nil, fmt.Errorf("failed to fetch audit logs for %s: %v", logType, err)
 		}
 
-		for _, errMsg := range errorResponse.Errors {
-			if strings.Contains(errMsg, "No log records found corresponding to the provided log type and time range") {
-				logrus.Warnf("No log records found for logType %s", logType)
-				return []map[string]string{}, nil
-			} else {
-				return nil, fmt.Errorf("failed to fetch audit logs for %s: %s", logType, errMsg)
+		source := gong.Source{RequestID: response.RequestID, Endpoint: endpoint, PageIndex: page}
+		TimeGenerated := time.Now().UTC().Format(iso8601Format)
+
+		for _, entry := range response.LogEntries {
+			logRecordMap, err := NewRecord(TimeGenerated, logType, entry)
+			if err != nil {
+				return nil, err
 			}
+			source.Annotate(logRecordMap)
+
+			mappedLogs = append(mappedLogs, logRecordMap)
 		}
+
+		if response.Records.Cursor == "" {
+			break
+		}
+		query.Set("cursor", response.Records.Cursor)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	return mappedLogs, nil
+}
+
+// Record is a GongAuditLogs record, the Gong log entry is kept as is. It stays a string column
+// as in the tables created by hand for older releases, whose column types cannot be changed.
+type Record struct {
+	TimeGenerated schema.DateTime `json:"TimeGenerated"`
+	LogType       string          `json:"logType"`
+	LogEntry      json.RawMessage `json:"logEntry" column:"string"`
+	// WorkspaceID is copied from the log entry so the record can be enriched with the workspace name
+	WorkspaceID string `json:"workspaceId"`
+}
+
+// NewRecord converts a single Gong log entry into the record format shipped to Sentinel.
+func NewRecord(timeGenerated string, logType string, entry interface{}) (map[string]string, error) {
+	logEntryJSON, err := json.Marshal(entry)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, fmt.Errorf("failed to marshal log entry to JSON: %v", err)
 	}
 
-	var response struct {
-		LogEntries []map[string]interface{} `json:"logEntries"`
+	return schema.Encode(Record{
+		TimeGenerated: schema.DateTime(timeGenerated),
+		LogType:       logType,
+		LogEntry:      logEntryJSON,
+		WorkspaceID:   entryWorkspaceID(logEntryJSON),
+	})
+}
+
+// entryWorkspaceID returns the workspace of a log entry, user activity logs keep it in their log record.
+func entryWorkspaceID(logEntry json.RawMessage) string {
+	var entry struct {
+		WorkspaceID json.RawMessage `json:"workspaceId"`
+		LogRecord   struct {
+			WorkspaceID json.RawMessage `json:"workspaceId"`
+		} `json:"logRecord"`
 	}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON response: %v", err)
+	if err := json.Unmarshal(logEntry, &entry); err != nil {
+		return ""
 	}
 
-	TimeGenerated := time.Now().UTC().Format(iso8601Format)
-	mappedLogs := make([]map[string]string, len(response.LogEntries))
-
-	for i, entry := range response.LogEntries {
-		logRecordMap := make(map[string]string)
-		logRecordMap["TimeGenerated"] = TimeGenerated
-		logRecordMap["logType"] = logType
+	for _, raw := range []json.RawMessage{entry.WorkspaceID, entry.LogRecord.WorkspaceID} {
+		// workspace IDs are numeric strings, decoded as json.Number in case a log type encodes them as numbers
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
 
-		logEntryJSON, err := json.Marshal(entry)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal log entry to JSON: %v", err)
+		var id interface{}
+		if err := decoder.Decode(&id); err != nil {
+			continue
 		}
-		logRecordMap["logEntry"] = string(logEntryJSON)
 
-		mappedLogs[i] = logRecordMap
+		switch value := id.(type) {
+		case string:
+			if value != "" {
+				return value
+			}
+		case json.Number:
+			return value.String()
+		}
 	}
 
-	return mappedLogs, nil
+	return ""
 }
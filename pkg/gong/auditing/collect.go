@@ -0,0 +1,72 @@
+package auditing
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/gong"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LogTypes returns the supported log types in a stable order.
+func LogTypes() []string {
+	var logTypes []string
+	for logType := range LogTypeStructMap {
+		logTypes = append(logTypes, logType)
+	}
+	sort.Strings(logTypes)
+
+	return logTypes
+}
+
+// GetAuditLogs fetches the given log types with at most workers concurrent requests.
+// Records are returned grouped per log type in the order of logTypes, regardless of which fetch finished first.
+func GetAuditLogs(client *gong.Client, logTypes []string, lookupHours int64, workers int) ([]map[string]string, error) {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	results := make([][]map[string]string, len(logTypes))
+	errs := make([]error, len(logTypes))
+
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				logType := logTypes[i]
+				start := time.Now()
+
+				results[i], errs[i] = GetAuditLogsForType(client, logType, lookupHours)
+
+				logrus.WithField("logType", logType).
+					WithField("total", len(results[i])).
+					WithField("duration", time.Since(start).Round(time.Millisecond).String()).
+					Info("fetched audit logs")
+			}
+		}()
+	}
+
+	for i := range logTypes {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+
+	var allLogs []map[string]string
+	for i, logType := range logTypes {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to retrieve Gong Audit Logs for logType %s: %v", logType, errs[i])
+		}
+
+		allLogs = append(allLogs, results[i]...)
+	}
+
+	return allLogs, nil
+}
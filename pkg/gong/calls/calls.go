@@ -1,54 +1,60 @@
 package calls
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/auditing"
+	"net/url"
 	"time"
 )
 
 const (
-	callsURL = "https://api.gong.io/v2/calls"
-)
-
-// GetCallIDs function to make a GET request and retrieve call IDs
-func GetCallIDs(accessKey string, secretKey string) ([]string, error) {
-	client := &http.Client{
-		Timeout: time.Second * 20,
-	}
-
-	req, err := http.NewRequest(http.MethodGet, callsURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %v", err)
-	}
-
-	// Set basic authentication
-	req.SetBasicAuth(accessKey, secretKey)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send HTTP request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch call IDs: %s", resp.Status)
-	}
+	callsPath = "/v2/calls"
 
-	var callIDs []string
-	var response struct {
-		Calls []struct {
-			ID string `json:"id"`
-		} `json:"calls"`
-	}
+	noCallsMessage = "No calls found corresponding to the provided filters"
+)
 
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode JSON response: %v", err)
-	}
+// Call is a call as listed by the calls endpoint.
+type Call struct {
+	ID          string `json:"id"`
+	WorkspaceID string `json:"workspaceId"`
+}
 
-	for _, call := range response.Calls {
-		callIDs = append(callIDs, call.ID)
+// GetCalls function to make a GET request and retrieve the calls started within the lookup window with their
+// workspace, following the cursor through all pages
+func GetCalls(client *gong.Client, lookupHours int64) ([]Call, error) {
+	var calls []Call
+
+	from, to := auditing.LookupWindow(time.Now(), lookupHours)
+	query := url.Values{}
+	query.Set("fromDateTime", from.Format(iso8601Format))
+	query.Set("toDateTime", to.Format(iso8601Format))
+
+	for {
+		var response struct {
+			Records struct {
+				Cursor string `json:"cursor"`
+			} `json:"records"`
+			Calls []Call `json:"calls"`
+		}
+
+		if err := client.Get(callsPath, query, &response); err != nil {
+			var apiErr *gong.APIError
+			if errors.As(err, &apiErr) && apiErr.Contains(noCallsMessage) {
+				break
+			}
+
+			return nil, fmt.Errorf("failed to fetch calls: %v", err)
+		}
+
+		calls = append(calls, response.Calls...)
+
+		if response.Records.Cursor == "" {
+			break
+		}
+		query.Set("cursor", response.Records.Cursor)
 	}
 
-	return callIDs, nil
+	return calls, nil
 }
@@ -0,0 +1,199 @@
+package calls
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/auditing"
+	"gong2sentinel/pkg/schema"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	extensiveCallsPath = "/v2/calls/extensive"
+
+	affiliationExternal = "External"
+)
+
+// Party is a participant of a call.
+type Party struct {
+	ID           string `json:"id"`
+	EmailAddress string `json:"emailAddress"`
+	Name         string `json:"name"`
+	Title        string `json:"title"`
+	UserID       string `json:"userId"`
+	SpeakerID    string `json:"speakerId"`
+	Affiliation  string `json:"affiliation"`
+	PhoneNumber  string `json:"phoneNumber"`
+}
+
+// ExtensiveCall is a call with its participants and CRM context.
+type ExtensiveCall struct {
+	MetaData struct {
+		ID            string `json:"id"`
+		URL           string `json:"url"`
+		Title         string `json:"title"`
+		Scheduled     string `json:"scheduled"`
+		Started       string `json:"started"`
+		Duration      int    `json:"duration"`
+		PrimaryUserID string `json:"primaryUserId"`
+		Direction     string `json:"direction"`
+		System        string `json:"system"`
+		Scope         string `json:"scope"`
+		Media         string `json:"media"`
+		Language      string `json:"language"`
+		WorkspaceID   string `json:"workspaceId"`
+		IsPrivate     bool   `json:"isPrivate"`
+	} `json:"metaData"`
+	Context json.RawMessage `json:"context"`
+	Parties []Party         `json:"parties"`
+}
+
+type extensiveCallsRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+	Filter struct {
+		FromDateTime string `json:"fromDateTime"`
+		ToDateTime   string `json:"toDateTime"`
+	} `json:"filter"`
+	ContentSelector struct {
+		Context       string `json:"context"`
+		ExposedFields struct {
+			Parties bool `json:"parties"`
+		} `json:"exposedFields"`
+	} `json:"contentSelector"`
+}
+
+// GetExtensiveCalls returns one record per call started within the lookup window,
+// with its participants, the domains of external parties and the CRM context.
+func GetExtensiveCalls(client *gong.Client, lookupHours int64) ([]map[string]string, error) {
+	from, to := auditing.LookupWindow(time.Now(), lookupHours)
+
+	request := extensiveCallsRequest{}
+	request.Filter.FromDateTime = from.Format(iso8601Format)
+	request.Filter.ToDateTime = to.Format(iso8601Format)
+	request.ContentSelector.Context = "Extended"
+	request.ContentSelector.ExposedFields.Parties = true
+
+	var records []map[string]string
+
+	for page := 0; ; page++ {
+		var response struct {
+			RequestID string `json:"requestId"`
+			Records   struct {
+				Cursor string `json:"cursor"`
+			} `json:"records"`
+			Calls []ExtensiveCall `json:"calls"`
+		}
+		if err := client.Post(extensiveCallsPath, request, &response); err != nil {
+			// a window without calls is answered with a 404
+			var apiErr *gong.APIError
+			if errors.As(err, &apiErr) && apiErr.Contains(noCallsMessage) {
+				break
+			}
+
+			return nil, fmt.Errorf("failed to fetch extensive calls: %v", err)
+		}
+
+		source := gong.Source{RequestID: response.RequestID, Endpoint: client.URL(extensiveCallsPath, nil), PageIndex: page}
+		timeGenerated := time.Now().UTC().Format(iso8601Format)
+
+		for _, call := range response.Calls {
+			record, err := callRecord(timeGenerated, call)
+			if err != nil {
+				return nil, err
+			}
+			source.Annotate(record)
+
+			records = append(records, record)
+		}
+
+		if response.Records.Cursor == "" {
+			break
+		}
+		request.Cursor = response.Records.Cursor
+	}
+
+	return records, nil
+}
+
+// Record is a GongCalls record.
+type Record struct {
+	TimeGenerated           schema.DateTime `json:"TimeGenerated"`
+	CallID                  string          `json:"callId"`
+	Title                   string          `json:"title"`
+	URL                     string          `json:"url"`
+	Scheduled               schema.DateTime `json:"scheduled"`
+	Started                 schema.DateTime `json:"started"`
+	Duration                int             `json:"duration"`
+	Direction               string          `json:"direction"`
+	System                  string          `json:"system"`
+	Scope                   string          `json:"scope"`
+	Media                   string          `json:"media"`
+	Language                string          `json:"language"`
+	IsPrivate               bool            `json:"isPrivate"`
+	WorkspaceID             string          `json:"workspaceId"`
+	PrimaryUserID           string          `json:"primaryUserId"`
+	HostEmail               string          `json:"hostEmail"`
+	HasExternalParticipants bool            `json:"hasExternalParticipants"`
+	// ExternalDomains is a comma separated list
+	ExternalDomains      string          `json:"externalDomains"`
+	ExternalParticipants []Party         `json:"externalParticipants"`
+	Parties              []Party         `json:"parties"`
+	CRMContext           json.RawMessage `json:"crmContext"`
+}
+
+func callRecord(timeGenerated string, call ExtensiveCall) (map[string]string, error) {
+	metaData := call.MetaData
+
+	var hostEmail string
+	var externalParties []Party
+	domains := map[string]bool{}
+
+	for _, party := range call.Parties {
+		if party.UserID != "" && party.UserID == metaData.PrimaryUserID {
+			hostEmail = party.EmailAddress
+		}
+
+		if party.Affiliation != affiliationExternal {
+			continue
+		}
+
+		externalParties = append(externalParties, party)
+		if at := strings.LastIndex(party.EmailAddress, "@"); at >= 0 {
+			domains[strings.ToLower(party.EmailAddress[at+1:])] = true
+		}
+	}
+
+	var externalDomains []string
+	for domain := range domains {
+		externalDomains = append(externalDomains, domain)
+	}
+	sort.Strings(externalDomains)
+
+	return schema.Encode(Record{
+		TimeGenerated:           schema.DateTime(timeGenerated),
+		CallID:                  metaData.ID,
+		Title:                   metaData.Title,
+		URL:                     metaData.URL,
+		Scheduled:               schema.DateTime(metaData.Scheduled),
+		Started:                 schema.DateTime(metaData.Started),
+		Duration:                metaData.Duration,
+		Direction:               metaData.Direction,
+		System:                  metaData.System,
+		Scope:                   metaData.Scope,
+		Media:                   metaData.Media,
+		Language:                metaData.Language,
+		IsPrivate:               metaData.IsPrivate,
+		WorkspaceID:             metaData.WorkspaceID,
+		PrimaryUserID:           metaData.PrimaryUserID,
+		HostEmail:               hostEmail,
+		HasExternalParticipants: len(externalParties) > 0,
+		ExternalDomains:         strings.Join(externalDomains, ","),
+		ExternalParticipants:    externalParties,
+		Parties:                 call.Parties,
+		CRMContext:              call.Context,
+	})
+}
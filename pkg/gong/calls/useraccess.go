@@ -1,21 +1,26 @@
 package calls
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/schema"
 	"time"
 )
 
 const (
-	iso8601Format = "2006-01-02T15:04:05Z"
-	userAccessURL = "https://api.gong.io/v2/calls/users-access"
+	iso8601Format  = "2006-01-02T15:04:05Z"
+	userAccessPath = "/v2/calls/users-access"
+
+	// userAccessChunkSize is the number of call IDs sent per request, keeping the request body and the
+	// paged access list bounded however many calls the window holds
+	userAccessChunkSize = 100
 )
 
 // PostRequestBody Define the struct for the POST request body
 type PostRequestBody struct {
+	Cursor string `json:"cursor,omitempty"`
 	Filter struct {
 		CallIds []string `json:"callIds"`
 	} `json:"filter"`
@@ -23,69 +28,80 @@ type PostRequestBody struct {
 
 // ResponseBody represents the response structure of the POST request
 type ResponseBody struct {
-	RequestID      string              `json:"requestId"`
-	CallAccessList []map[string]string `json:"callAccessList"`
+	RequestID string `json:"requestId"`
+	Records   struct {
+		Cursor string `json:"cursor"`
+	} `json:"records"`
+	CallAccessList []map[string]interface{} `json:"callAccessList"`
 }
 
-// GetUserAccess Function to make a POST request with filtered call IDs
-func GetUserAccess(accessKey string, secretKey string, callIds []string) ([]map[string]string, error) {
-	now := time.Now().UTC().Format(iso8601Format)
-	client := &http.Client{
-		Timeout: time.Second * 20,
-	}
-
-	// Create the POST request body with the filtered call IDs
-	postRequestBody := &PostRequestBody{
-		Filter: struct {
-			CallIds []string `json:"callIds"`
-		}{CallIds: callIds},
-	}
-
-	// Convert request body to JSON
-	jsonData, err := json.Marshal(postRequestBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body to JSON: %v", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPost, userAccessURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %v", err)
-	}
-
-	// Set basic authentication
-	req.SetBasicAuth(accessKey, secretKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send HTTP request: %v", err)
-	}
-	defer resp.Body.Close()
+// UserAccessRecord is a GongCallUserAccess record holding the access list of one call. The list stays a
+// string column as in the tables created by hand for older releases, whose column types cannot be changed.
+type UserAccessRecord struct {
+	TimeGenerated  schema.DateTime `json:"TimeGenerated"`
+	CallAccessList json.RawMessage `json:"callAccessList" column:"string"`
+	// WorkspaceID is the workspace of the call, taken from the calls listing as the access list does not have it
+	WorkspaceID string `json:"workspaceId"`
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to send POST request: %s", resp.Status)
+// GetUserAccess Function to make POST requests with the IDs of the given calls, in chunks of userAccessChunkSize
+// and following the cursor through all pages of each chunk
+func GetUserAccess(client *gong.Client, calls []Call) ([]map[string]string, error) {
+	workspaceIDs := make(map[string]string, len(calls))
+	for _, call := range calls {
+		workspaceIDs[call.ID] = call.WorkspaceID
 	}
 
-	// Read the response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
-	}
+	now := time.Now().UTC().Format(iso8601Format)
+	callAccessList := []map[string]string{}
 
-	// Unmarshal the response body into the structured representation
-	var responseBody ResponseBody
-	if err := json.Unmarshal(body, &responseBody); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response body: %v", err)
-	}
+	for start := 0; start < len(calls); start += userAccessChunkSize {
+		end := min(start+userAccessChunkSize, len(calls))
 
-	// Convert CallAccessList to the desired format
-	callAccessList := make([]map[string]string, len(responseBody.CallAccessList))
+		postRequestBody := &PostRequestBody{}
+		for _, call := range calls[start:end] {
+			postRequestBody.Filter.CallIds = append(postRequestBody.Filter.CallIds, call.ID)
+		}
 
-	for i, item := range responseBody.CallAccessList {
-		callAccessList[i] = map[string]string{
-			"TimeGenerated":  now,
-			"requestId":      item["requestId"],
-			"callAccessList": item["callAccessList"],
+		for page := 0; ; page++ {
+			var responseBody ResponseBody
+			if err := client.Post(userAccessPath, postRequestBody, &responseBody); err != nil {
+				// calls deleted since they were listed are answered with a 404
+				var apiErr *gong.APIError
+				if errors.As(err, &apiErr) && apiErr.Contains(noCallsMessage) {
+					break
+				}
+
+				return nil, fmt.Errorf("failed to send POST request: %v", err)
+			}
+
+			source := gong.Source{RequestID: responseBody.RequestID, Endpoint: client.URL(userAccessPath, nil), PageIndex: page}
+
+			for _, item := range responseBody.CallAccessList {
+				itemJSON, err := json.Marshal(item)
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal call access entry to JSON: %v", err)
+				}
+
+				callID, _ := item["callId"].(string)
+
+				record, err := schema.Encode(UserAccessRecord{
+					TimeGenerated:  schema.DateTime(now),
+					CallAccessList: itemJSON,
+					WorkspaceID:    workspaceIDs[callID],
+				})
+				if err != nil {
+					return nil, err
+				}
+				source.Annotate(record)
+
+				callAccessList = append(callAccessList, record)
+			}
+
+			if responseBody.Records.Cursor == "" {
+				break
+			}
+			postRequestBody.Cursor = responseBody.Records.Cursor
 		}
 	}
 
@@ -0,0 +1,216 @@
+package gong
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gong2sentinel/pkg/schema"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBaseURL = "https://api.gong.io"
+
+	// DefaultRequestsPerSecond is the Gong API rate limit for a single company.
+	DefaultRequestsPerSecond = 3
+
+	requestTimeout = time.Second * 50
+
+	maxRateLimitRetries = 5
+	defaultRetryAfter   = time.Second * 2
+)
+
+// Client holds the Gong API location and credentials shared by all collectors.
+// It is safe for concurrent use and spaces out requests to stay within the rate limit.
+type Client struct {
+	baseURL      string
+	accessKey    string
+	accessSecret string
+
+	httpClient *http.Client
+
+	limitMu     sync.Mutex
+	interval    time.Duration
+	nextRequest time.Time
+}
+
+// APIError is returned when Gong answers with a non-200 status code.
+type APIError struct {
+	StatusCode int
+	RequestID  string   `json:"requestId"`
+	Errors     []string `json:"errors"`
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("gong api returned status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("gong api returned status %d: %s", e.StatusCode, strings.Join(e.Errors, "; "))
+}
+
+// Contains reports whether one of the Gong error messages contains msg.
+func (e *APIError) Contains(msg string) bool {
+	for _, errMsg := range e.Errors {
+		if strings.Contains(errMsg, msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func New(baseURL, accessKey, accessSecret string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		accessKey:    accessKey,
+		accessSecret: accessSecret,
+		httpClient: &http.Client{
+			Timeout: requestTimeout,
+		},
+		interval: time.Second / DefaultRequestsPerSecond,
+	}
+}
+
+// SetRateLimit changes the maximum number of requests per second sent to Gong, zero or less disables the limit.
+func (c *Client) SetRateLimit(requestsPerSecond int) {
+	c.limitMu.Lock()
+	defer c.limitMu.Unlock()
+
+	c.interval = 0
+	if requestsPerSecond > 0 {
+		c.interval = time.Second / time.Duration(requestsPerSecond)
+	}
+}
+
+// wait blocks until the next request slot is available.
+func (c *Client) wait() {
+	c.limitMu.Lock()
+
+	now := time.Now()
+	slot := c.nextRequest
+	if slot.Before(now) {
+		slot = now
+	}
+	c.nextRequest = slot.Add(c.interval)
+
+	c.limitMu.Unlock()
+
+	time.Sleep(time.Until(slot))
+}
+
+// URL returns the absolute URL for an API path such as /v2/logs.
+func (c *Client) URL(path string, query url.Values) string {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	return u
+}
+
+// Get performs an authenticated GET request and decodes the JSON response into out.
+func (c *Client) Get(path string, query url.Values, out interface{}) error {
+	return c.do(http.MethodGet, c.URL(path, query), nil, out)
+}
+
+// Post performs an authenticated POST request with a JSON body and decodes the JSON response into out.
+func (c *Client) Post(path string, body interface{}, out interface{}) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body to JSON: %v", err)
+	}
+
+	return c.do(http.MethodPost, c.URL(path, nil), jsonData, out)
+}
+
+func (c *Client) do(method, url string, body []byte, out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		c.wait()
+
+		retryAfter, err := c.doOnce(method, url, body, out)
+		if retryAfter == 0 || attempt >= maxRateLimitRetries {
+			return err
+		}
+
+		time.Sleep(retryAfter)
+	}
+}
+
+// doOnce sends a single request, it returns a non-zero duration when Gong asks to retry later.
+func (c *Client) doOnce(method, url string, body []byte, out interface{}) (time.Duration, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+
+	req.SetBasicAuth(c.accessKey, c.accessSecret)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		// not every error response carries a JSON body, so ignore decoding failures
+		_ = json.Unmarshal(respBody, apiErr)
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return retryAfter(resp.Header.Get("Retry-After")), apiErr
+		}
+
+		return 0, apiErr
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal JSON response: %v", err)
+	}
+
+	return 0, nil
+}
+
+func retryAfter(header string) time.Duration {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	return defaultRetryAfter
+}
+
+// Source identifies the exact Gong API call a record was collected from, its fields are the source columns.
+type Source struct {
+	RequestID string `json:"requestId"`
+	Endpoint  string `json:"endpoint"`
+	PageIndex int    `json:"pageIndex"`
+}
+
+// Annotate adds the source columns to a record so any row in Sentinel can be traced back to its API call.
+func (s Source) Annotate(record map[string]string) {
+	// the source only has text and number columns, which always encode
+	_ = schema.Merge(record, s)
+}
@@ -0,0 +1,127 @@
+package crm
+
+import (
+	"encoding/json"
+	"fmt"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/snapshot"
+	"gong2sentinel/pkg/schema"
+	"strings"
+	"time"
+)
+
+const (
+	integrationsPath = "/v2/crm/integrations"
+
+	kindIntegration = "crm-integration"
+	ownerField      = "ownerEmail"
+
+	EventIntegrationAdded    = "integration-added"
+	EventIntegrationRemoved  = "integration-removed"
+	EventIntegrationModified = "integration-modified"
+	EventOwnerChanged        = "owner-changed"
+)
+
+// OwnerColumns are added to the snapshot records of CRM integrations.
+type OwnerColumns struct {
+	OwnerEmail string `json:"ownerEmail"`
+	// OldOwnerEmail is only set on owner-changed events
+	OldOwnerEmail string `json:"oldOwnerEmail,omitempty"`
+}
+
+// GetSnapshot fetches the configured CRM integrations.
+func GetSnapshot(client *gong.Client) (snapshot.Snapshot, snapshot.Sources, error) {
+	var response struct {
+		RequestID    string            `json:"requestId"`
+		Integrations []json.RawMessage `json:"integrations"`
+	}
+	if err := client.Get(integrationsPath, nil, &response); err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch CRM integrations: %v", err)
+	}
+
+	source := gong.Source{RequestID: response.RequestID, Endpoint: client.URL(integrationsPath, nil)}
+	current := snapshot.Snapshot{}
+	sources := snapshot.Sources{snapshot.Scope(kindIntegration, ""): source}
+
+	for _, definition := range response.Integrations {
+		var integration struct {
+			IntegrationID string `json:"integrationId"`
+			Name          string `json:"name"`
+		}
+		if err := json.Unmarshal(definition, &integration); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal CRM integration: %v", err)
+		}
+
+		normalized, err := snapshot.Normalize(definition)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to normalize CRM integration %s: %v", integration.IntegrationID, err)
+		}
+
+		current[snapshot.Key(kindIntegration, integration.IntegrationID)] = snapshot.Item{
+			Kind:       kindIntegration,
+			ID:         integration.IntegrationID,
+			Name:       integration.Name,
+			Definition: normalized,
+			Source:     source,
+		}
+	}
+
+	return current, sources, nil
+}
+
+// GetIntegrationChanges fetches the current CRM integrations and returns state change events compared to previous.
+// The returned snapshot should only be persisted once the records have been shipped.
+func GetIntegrationChanges(client *gong.Client, previous snapshot.Snapshot) ([]map[string]string, snapshot.Snapshot, error) {
+	current, sources, err := GetSnapshot(client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return Diff(previous, current, sources, time.Now()), current, nil
+}
+
+// Diff returns the CRM integration events between two snapshots, modifications that
+// change the integration owner are reported as owner-changed so they can be alerted on.
+func Diff(previous, current snapshot.Snapshot, sources snapshot.Sources, now time.Time) []map[string]string {
+	records := snapshot.Diff(previous, current, sources, now)
+
+	for _, record := range records {
+		key := snapshot.Key(kindIntegration, record["itemId"])
+		oldOwner := owner(previous[key].Definition)
+		newOwner := owner(current[key].Definition)
+
+		var columns OwnerColumns
+
+		switch record["event"] {
+		case snapshot.EventAdded:
+			record["event"] = EventIntegrationAdded
+			columns.OwnerEmail = newOwner
+		case snapshot.EventRemoved:
+			record["event"] = EventIntegrationRemoved
+			columns.OwnerEmail = oldOwner
+		case snapshot.EventModified:
+			record["event"] = EventIntegrationModified
+			columns.OwnerEmail = newOwner
+
+			for _, field := range strings.Split(record["changedFields"], ",") {
+				if field == ownerField {
+					record["event"] = EventOwnerChanged
+					columns.OldOwnerEmail = oldOwner
+				}
+			}
+		}
+
+		// owner columns are text, which always encodes
+		_ = schema.Merge(record, columns)
+	}
+
+	return records
+}
+
+func owner(definition json.RawMessage) string {
+	var integration map[string]interface{}
+	_ = json.Unmarshal(definition, &integration)
+
+	ownerEmail, _ := integration[ownerField].(string)
+	return ownerEmail
+}
@@ -0,0 +1,86 @@
+package crm
+
+import (
+	"encoding/json"
+	"gong2sentinel/pkg/gong/snapshot"
+	"strings"
+	"testing"
+	"time"
+)
+
+func integrations(definitions ...string) snapshot.Snapshot {
+	s := snapshot.Snapshot{}
+	for _, definition := range definitions {
+		var integration struct {
+			IntegrationID string `json:"integrationId"`
+		}
+		_ = json.Unmarshal([]byte(definition), &integration)
+
+		s[snapshot.Key(kindIntegration, integration.IntegrationID)] = snapshot.Item{
+			Kind:       kindIntegration,
+			ID:         integration.IntegrationID,
+			Definition: json.RawMessage(definition),
+		}
+	}
+
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous snapshot.Snapshot
+		current  snapshot.Snapshot
+		want     []string
+	}{
+		{
+			name:    "added integration",
+			current: integrations(`{"integrationId":"c1","ownerEmail":"a@example.com","status":"ACTIVE"}`),
+			want:    []string{"integration-added c1 a@example.com "},
+		},
+		{
+			name:     "owner changed",
+			previous: integrations(`{"integrationId":"c1","ownerEmail":"a@example.com","status":"ACTIVE"}`),
+			current:  integrations(`{"integrationId":"c1","ownerEmail":"b@example.com","status":"ACTIVE"}`),
+			want:     []string{"owner-changed c1 b@example.com a@example.com"},
+		},
+		{
+			name:     "owner changed along with other fields",
+			previous: integrations(`{"integrationId":"c1","ownerEmail":"a@example.com","status":"ACTIVE"}`),
+			current:  integrations(`{"integrationId":"c1","ownerEmail":"b@example.com","status":"PAUSED"}`),
+			want:     []string{"owner-changed c1 b@example.com a@example.com"},
+		},
+		{
+			name:     "modified without owner change",
+			previous: integrations(`{"integrationId":"c1","ownerEmail":"a@example.com","status":"ACTIVE"}`),
+			current:  integrations(`{"integrationId":"c1","ownerEmail":"a@example.com","status":"PAUSED"}`),
+			want:     []string{"integration-modified c1 a@example.com "},
+		},
+		{
+			name:     "owner removed",
+			previous: integrations(`{"integrationId":"c1","ownerEmail":"a@example.com"}`),
+			current:  integrations(`{"integrationId":"c1"}`),
+			want:     []string{"owner-changed c1  a@example.com"},
+		},
+		{
+			name:     "removed integration keeps its last owner",
+			previous: integrations(`{"integrationId":"c1","ownerEmail":"a@example.com"}`),
+			current:  snapshot.Snapshot{},
+			want:     []string{"integration-removed c1 a@example.com "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := Diff(tt.previous, tt.current, snapshot.Sources{}, time.Now())
+
+			var got []string
+			for _, record := range records {
+				got = append(got, strings.Join([]string{record["event"], record["itemId"], record["ownerEmail"], record["oldOwnerEmail"]}, " "))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got events\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
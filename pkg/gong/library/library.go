@@ -0,0 +1,158 @@
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/workspaces"
+	"gong2sentinel/pkg/schema"
+	"net/url"
+	"time"
+)
+
+const (
+	iso8601Format = "2006-01-02T15:04:05Z"
+
+	foldersPath       = "/v2/library/folders"
+	folderContentPath = "/v2/library/folder-content"
+)
+
+// Folder is a Gong Library folder. The folders endpoint only returns public folders, the ones every user
+// with Library access can see, so the API has no sharing attributes.
+type Folder struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	ParentFolderID string `json:"parentFolderId"`
+	CreatedBy      string `json:"createdBy"`
+	Updated        string `json:"updated"`
+}
+
+// FolderCall is a call, or a snippet of it, stored in a Library folder.
+type FolderCall struct {
+	ID      string          `json:"id"`
+	Title   string          `json:"title"`
+	Note    string          `json:"note"`
+	AddedBy string          `json:"addedBy"`
+	Created string          `json:"created"`
+	URL     string          `json:"url"`
+	Snippet json.RawMessage `json:"snippet,omitempty"`
+}
+
+// Record is a GongLibraryFolders record of a call, or a snippet of it, in a Library folder. The API only returns
+// public folders, so every call in the table is exposed to every user with Library access. A folder without
+// calls is reported by a single record without call columns so the inventory still lists it.
+type Record struct {
+	TimeGenerated  schema.DateTime `json:"TimeGenerated"`
+	FolderID       string          `json:"folderId"`
+	FolderName     string          `json:"folderName"`
+	ParentFolderID string          `json:"parentFolderId"`
+	WorkspaceID    string          `json:"workspaceId"`
+	CreatedBy      string          `json:"createdBy"`
+	Updated        schema.DateTime `json:"updated"`
+	// CallCount is the number of calls in the folder, the folder has one record per call
+	CallCount   int             `json:"callCount"`
+	CallID      string          `json:"callId"`
+	CallTitle   string          `json:"callTitle"`
+	CallNote    string          `json:"callNote"`
+	CallAddedBy string          `json:"callAddedBy"`
+	CallAdded   schema.DateTime `json:"callAdded"`
+	CallURL     string          `json:"callUrl"`
+	Snippet     json.RawMessage `json:"snippet,omitempty"`
+}
+
+// GetFolderInventory returns one record per call in a public Library folder of the given workspaces, and one
+// record for each folder without calls.
+func GetFolderInventory(client *gong.Client, allWorkspaces []workspaces.Workspace) ([]map[string]string, error) {
+	var records []map[string]string
+
+	for _, workspace := range allWorkspaces {
+		query := url.Values{}
+		query.Set("workspaceId", workspace.ID)
+
+		var response struct {
+			RequestID string   `json:"requestId"`
+			Folders   []Folder `json:"folders"`
+		}
+		if err := client.Get(foldersPath, query, &response); err != nil {
+			return nil, fmt.Errorf("failed to fetch library folders for workspace %s: %v", workspace.ID, err)
+		}
+
+		for _, folder := range response.Folders {
+			calls, source, err := getFolderCalls(client, folder.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			folderRecords, err := folderRecords(folder, workspace.ID, calls, time.Now())
+			if err != nil {
+				return nil, err
+			}
+
+			for _, record := range folderRecords {
+				source.Annotate(record)
+				records = append(records, record)
+			}
+		}
+	}
+
+	return records, nil
+}
+
+// folderRecords returns the records of a folder, one per call or a single one without call when it is empty.
+func folderRecords(folder Folder, workspaceID string, calls []FolderCall, now time.Time) ([]map[string]string, error) {
+	base := Record{
+		TimeGenerated:  schema.DateTime(now.UTC().Format(iso8601Format)),
+		FolderID:       folder.ID,
+		FolderName:     folder.Name,
+		ParentFolderID: folder.ParentFolderID,
+		WorkspaceID:    workspaceID,
+		CreatedBy:      folder.CreatedBy,
+		Updated:        schema.DateTime(folder.Updated),
+		CallCount:      len(calls),
+	}
+
+	if len(calls) == 0 {
+		record, err := schema.Encode(base)
+		if err != nil {
+			return nil, err
+		}
+
+		return []map[string]string{record}, nil
+	}
+
+	records := make([]map[string]string, 0, len(calls))
+	for _, call := range calls {
+		callRecord := base
+		callRecord.CallID = call.ID
+		callRecord.CallTitle = call.Title
+		callRecord.CallNote = call.Note
+		callRecord.CallAddedBy = call.AddedBy
+		callRecord.CallAdded = schema.DateTime(call.Created)
+		callRecord.CallURL = call.URL
+		callRecord.Snippet = call.Snippet
+
+		record, err := schema.Encode(callRecord)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func getFolderCalls(client *gong.Client, folderID string) ([]FolderCall, gong.Source, error) {
+	query := url.Values{}
+	query.Set("folderId", folderID)
+
+	var response struct {
+		RequestID string       `json:"requestId"`
+		Calls     []FolderCall `json:"calls"`
+	}
+	if err := client.Get(folderContentPath, query, &response); err != nil {
+		return nil, gong.Source{}, fmt.Errorf("failed to fetch content of library folder %s: %v", folderID, err)
+	}
+
+	return response.Calls, gong.Source{RequestID: response.RequestID, Endpoint: client.URL(folderContentPath, query)}, nil
+}
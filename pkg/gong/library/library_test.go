@@ -0,0 +1,53 @@
+package library
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFolderRecords(t *testing.T) {
+	folder := Folder{ID: "f1", Name: "Best calls", Updated: "2024-04-01T10:00:00Z"}
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		calls       []FolderCall
+		wantCallIDs []string
+	}{
+		{name: "empty folder is listed once", wantCallIDs: []string{""}},
+		{
+			name: "one record per call",
+			calls: []FolderCall{
+				{ID: "c1", Title: "Intro", Created: "2024-04-02T10:00:00Z"},
+				{ID: "c2", Title: "Demo", Snippet: json.RawMessage(`{"fromSec":10,"toSec":20}`)},
+			},
+			wantCallIDs: []string{"c1", "c2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := folderRecords(folder, "w1", tt.calls, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != len(tt.wantCallIDs) {
+				t.Fatalf("got %d records, want %d", len(records), len(tt.wantCallIDs))
+			}
+
+			for i, record := range records {
+				if record["callId"] != tt.wantCallIDs[i] {
+					t.Errorf("record %d: got call %q, want %q", i, record["callId"], tt.wantCallIDs[i])
+				}
+				if record["folderId"] != "f1" || record["workspaceId"] != "w1" {
+					t.Errorf("record %d: got folder %s in workspace %s", i, record["folderId"], record["workspaceId"])
+				}
+				if want := len(tt.calls); record["callCount"] != strconv.Itoa(want) {
+					t.Errorf("record %d: got callCount %s, want %d", i, record["callCount"], want)
+				}
+			}
+		})
+	}
+}
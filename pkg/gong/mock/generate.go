@@ -0,0 +1,417 @@
+package mock
+
+import (
+	"fmt"
+	"gong2sentinel/pkg/gong/auditing"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	firstNames = []string{"Alice", "Bob", "Carol", "Dave", "Erin", "Frank", "Grace", "Heidi", "Ivan", "Judy"}
+	lastNames  = []string{"Smith", "Jones", "Peeters", "Janssens", "Maes", "Dubois", "Garcia", "Mertens"}
+	userAgents = []string{
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Edg/124.0",
+		"Gong/5.12.0 (iPhone; iOS 17.4)",
+	}
+	prospectDomains = []string{"acme.example", "globex.example", "initech.example"}
+	folderNames     = []string{"Best calls", "Customer references", "Onboarding"}
+	trackerNames    = map[string][]string{
+		"Competitors": {"globex", "initech"},
+		"Pricing":     {"price", "discount", "budget"},
+		"Security":    {"SOC 2", "GDPR", "SSO"},
+	}
+	scorecardNames = []string{"Discovery call", "Demo"}
+	profileNames   = []string{"Administrator", "Manager", "Sales Rep"}
+	titles         = []string{"Account Executive", "Sales Development Representative", "Sales Manager", "Customer Success Manager", "Gong Administrator"}
+	devices        = []string{"WEB", "IOS", "ANDROID"}
+	uris           = []string{"/call", "/calls/search", "/library", "/settings/users", "/deals"}
+)
+
+func (s *Server) generate() {
+	now := time.Now().UTC()
+
+	for i := 0; i < s.opts.Users; i++ {
+		first := firstNames[i%len(firstNames)]
+		last := lastNames[(i/len(firstNames))%len(lastNames)]
+
+		s.users = append(s.users, user{
+			ID:       s.numericID(),
+			Email:    fmt.Sprintf("%s.%s%d@example.com", first, last, i),
+			FullName: first + " " + last,
+			Title:    titles[i%len(titles)],
+			Active:   !s.chance(0.1),
+			Created:  now.Add(-time.Duration(s.intn(1000)) * time.Hour * 24).Truncate(time.Second),
+			Settings: map[string]bool{
+				"webConferencesRecorded":        true,
+				"preventWebConferenceRecording": false,
+				"telephonyCallsImported":        s.chance(0.5),
+				"emailsImported":                s.chance(0.5),
+				"preventEmailImport":            false,
+				"nonRecordedMeetingsImported":   s.chance(0.5),
+				"gongConnectEnabled":            true,
+			},
+		})
+	}
+
+	for _, name := range []string{"Sales", "Customer Success"} {
+		workspaceID := s.numericID()
+		s.workspaces = append(s.workspaces, map[string]interface{}{
+			"id":          workspaceID,
+			"name":        name,
+			"description": name + " team workspace",
+		})
+
+		for j, profileName := range profileNames {
+			p := profile{
+				WorkspaceID: workspaceID,
+				Definition: map[string]interface{}{
+					"id":          s.numericID(),
+					"name":        profileName,
+					"description": profileName + " permissions",
+					"callsAccess": map[string]interface{}{
+						"permissionLevel": []string{"all", "managers-team", "own"}[j],
+					},
+					"manageGeneralBusinessSettings": j == 0,
+					"exportCallsAndCoachingData":    j == 0,
+					"deleteCalls":                   j == 0,
+				},
+			}
+
+			// users are only assigned in the first workspace: the first user is the
+			// administrator and the others alternate between manager and sales rep
+			for k, u := range s.users {
+				profileIndex := 0
+				if k > 0 {
+					profileIndex = 1 + (k+1)%2
+				}
+
+				if len(s.workspaces) == 1 && profileIndex == j {
+					p.UserIDs = append(p.UserIDs, u.ID)
+				}
+			}
+
+			s.profiles = append(s.profiles, p)
+		}
+	}
+
+	for i := range s.users {
+		s.users[i].History = s.settingsHistory(s.users[i], now)
+	}
+
+	for i := 0; i < s.opts.Calls; i++ {
+		started := s.timeInWindow(now)
+		host := s.users[s.intn(len(s.users))]
+
+		s.calls = append(s.calls, map[string]interface{}{
+			"id":            s.numericID(),
+			"url":           fmt.Sprintf("https://app.gong.io/call?id=%d", i),
+			"title":         fmt.Sprintf("Discovery call #%d", i+1),
+			"scheduled":     started.Format(iso8601Format),
+			"started":       started.Format(iso8601Format),
+			"duration":      300 + s.intn(3300),
+			"primaryUserId": host.ID,
+			"direction":     "Conference",
+			"system":        "Zoom",
+			"scope":         []string{"Internal", "External"}[s.intn(2)],
+			"media":         "Video",
+			"language":      "eng",
+			"isPrivate":     s.chance(0.1),
+			"workspaceId":   s.workspaces[s.intn(len(s.workspaces))]["id"],
+		})
+	}
+
+	for _, call := range s.calls {
+		s.parties[call["id"].(string)] = s.callParties(call)
+	}
+
+	for _, workspace := range s.workspaces {
+		for _, name := range folderNames {
+			f := folder{
+				WorkspaceID: workspace["id"].(string),
+				Definition: map[string]interface{}{
+					"id":        s.numericID(),
+					"name":      name,
+					"createdBy": s.users[s.intn(len(s.users))].ID,
+					"updated":   s.timeInWindow(now).Format(iso8601Format),
+				},
+			}
+
+			numCalls := 0
+			if len(s.calls) > 0 {
+				numCalls = s.intn(5)
+			}
+
+			for k := 0; k < numCalls; k++ {
+				call := s.calls[s.intn(len(s.calls))]
+				f.Calls = append(f.Calls, map[string]interface{}{
+					"id":      call["id"],
+					"title":   call["title"],
+					"note":    "",
+					"addedBy": s.users[s.intn(len(s.users))].ID,
+					"created": s.timeInWindow(now).Format(iso8601Format),
+					"url":     call["url"],
+				})
+			}
+
+			s.folders = append(s.folders, f)
+		}
+	}
+
+	sort.Slice(s.calls, func(i, j int) bool {
+		return s.calls[i]["started"].(string) < s.calls[j]["started"].(string)
+	})
+
+	// log types are iterated in a stable order so a given seed always yields the same data
+	for _, logType := range auditing.LogTypes() {
+		entries := make([]map[string]interface{}, s.opts.LogEntries)
+		for i := range entries {
+			entries[i] = s.logEntry(logType, s.timeInWindow(now))
+		}
+
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i]["eventTime"].(time.Time).Before(entries[j]["eventTime"].(time.Time))
+		})
+
+		s.logs[logType] = entries
+	}
+
+	s.integrations = []map[string]interface{}{{
+		"integrationId": "crm-1",
+		"name":          "Salesforce",
+		"ownerEmail":    s.users[0].Email,
+		"crmSystem":     "Salesforce",
+		"status":        "ACTIVE",
+	}}
+
+	// keep generation order stable: appending new generators must not change earlier seeded data
+	for _, workspace := range s.workspaces {
+		for _, name := range []string{"Competitors", "Pricing", "Security"} {
+			s.trackers = append(s.trackers, map[string]interface{}{
+				"trackerId":        s.numericID(),
+				"trackerName":      name,
+				"workspaceId":      workspace["id"],
+				"languageKeywords": []map[string]interface{}{{"language": "eng", "keywords": trackerNames[name]}},
+				"affiliation":      []string{"Anyone", "Company", "NonCompany"}[s.intn(3)],
+				"partOfQuestion":   false,
+				"created":          s.timeInWindow(now).Format(iso8601Format),
+				"creatorUserId":    s.users[0].ID,
+				"updated":          s.timeInWindow(now).Format(iso8601Format),
+				"updaterUserId":    s.users[0].ID,
+			})
+		}
+
+		for _, name := range scorecardNames {
+			s.scorecards = append(s.scorecards, map[string]interface{}{
+				"scorecardId":   s.numericID(),
+				"scorecardName": name,
+				"workspaceId":   workspace["id"],
+				"enabled":       true,
+				"reviewMethod":  "AUTOMATIC",
+				"created":       s.timeInWindow(now).Format(iso8601Format),
+				"updated":       s.timeInWindow(now).Format(iso8601Format),
+				"updaterUserId": s.users[0].ID,
+				"questions": []map[string]interface{}{
+					{"questionId": s.numericID(), "questionText": "Did the rep confirm the budget?", "isOverall": false},
+					{"questionId": s.numericID(), "questionText": "Overall score", "isOverall": true},
+				},
+			})
+		}
+	}
+}
+
+// callParties returns the host of the call and, for external calls, some participants of a prospect.
+func (s *Server) callParties(call map[string]interface{}) []map[string]interface{} {
+	var parties []map[string]interface{}
+
+	for _, u := range s.users {
+		if u.ID == call["primaryUserId"] {
+			parties = append(parties, map[string]interface{}{
+				"id":           s.numericID(),
+				"emailAddress": u.Email,
+				"name":         u.FullName,
+				"title":        u.Title,
+				"userId":       u.ID,
+				"speakerId":    s.numericID(),
+				"affiliation":  "Internal",
+			})
+		}
+	}
+
+	if call["scope"] == "External" {
+		domain := prospectDomains[s.intn(len(prospectDomains))]
+		for i := 0; i < 1+s.intn(3); i++ {
+			first := firstNames[s.intn(len(firstNames))]
+			parties = append(parties, map[string]interface{}{
+				"id":           s.numericID(),
+				"emailAddress": strings.ToLower(first) + "@" + domain,
+				"name":         first + " Prospect",
+				"title":        "Procurement",
+				"speakerId":    s.numericID(),
+				"affiliation":  "External",
+			})
+		}
+	}
+
+	return parties
+}
+
+// settingsHistory records the initial value of every setting at creation time,
+// some users then flip one setting to its current value within the window.
+func (s *Server) settingsHistory(u user, now time.Time) []map[string]interface{} {
+	var settings []string
+	for setting := range u.Settings {
+		settings = append(settings, setting)
+	}
+	sort.Strings(settings)
+
+	changed := ""
+	if s.chance(0.3) {
+		changed = settings[s.intn(len(settings))]
+	}
+
+	var history []map[string]interface{}
+	for _, setting := range settings {
+		history = append(history, map[string]interface{}{
+			"setting":   setting,
+			"value":     u.Settings[setting] != (setting == changed),
+			"startTime": u.Created.Format(iso8601Format),
+		})
+	}
+
+	if changed != "" {
+		history = append(history, map[string]interface{}{
+			"setting":   changed,
+			"value":     u.Settings[changed],
+			"startTime": s.timeInWindow(now).Format(iso8601Format),
+		})
+	}
+
+	return history
+}
+
+func (s *Server) logEntry(logType string, eventTime time.Time) map[string]interface{} {
+	u := s.users[s.intn(len(s.users))]
+	callID := s.randomCallID()
+
+	entry := map[string]interface{}{
+		"userEmailAddress": u.Email,
+		"userFullName":     u.FullName,
+		"eventTime":        eventTime,
+	}
+
+	switch logType {
+	case "AccessLog":
+		uri := uris[s.intn(len(uris))]
+		entry["userId"] = u.ID
+		entry["logRecord"] = map[string]interface{}{
+			"response_headers": map[string]interface{}{
+				"x-traceid":    s.requestID(),
+				"content-type": "text/html",
+				"x-iid":        s.requestID(),
+			},
+			"protocol": "HTTP/1.1",
+			"method":   "GET",
+			"request_headers": map[string]interface{}{
+				"referer":         "https://app.gong.io/home",
+				"x-forwarded-for": s.ipAddress(),
+				"user-agent":      userAgents[s.intn(len(userAgents))],
+			},
+			"elapsed_time":   5 + s.intn(500),
+			"requested_url":  "https://app.gong.io" + uri,
+			"message":        "GET " + uri,
+			"mdc":            map[string]interface{}{"xtid": s.requestID()},
+			"content_length": 512 + s.intn(65536),
+			"requested_uri":  uri,
+			"status":         200,
+		}
+	case "UserActivityLog":
+		entry["userId"] = u.ID
+		entry["logRecord"] = map[string]interface{}{
+			"tableChanges": []map[string]interface{}{{
+				"preSnapshotTimestamp":  eventTime.Add(-time.Second),
+				"postSnapshotTimestamp": eventTime,
+				"rowChanges": []map[string]interface{}{{
+					"primaryKeyColumns": []map[string]interface{}{{
+						"columnValue": u.ID,
+						"columnName":  "user_id",
+					}},
+					"columnChanges": []map[string]interface{}{{
+						"newValue":   "true",
+						"oldValue":   "false",
+						"operation":  "UPDATE",
+						"columnName": "is_active",
+					}},
+				}},
+				"tableName": "users",
+			}},
+			"action": nil,
+			"httpRequest": map[string]interface{}{
+				"referrerUri": "https://app.gong.io/settings/users",
+				"clientIp":    s.ipAddress(),
+				"verb":        "POST",
+				"endpointUri": "/ajax/settings/users/update",
+				"body":        "",
+				"parameters":  []interface{}{},
+			},
+			"customData":  []interface{}{},
+			"workspaceId": s.workspaces[s.intn(len(s.workspaces))]["id"],
+		}
+	case "UserCallPlay":
+		entry["userId"] = u.ID
+		entry["logRecord"] = s.playRecord(callID, eventTime, s.intn(100))
+	case "ExternallySharedCallAccess":
+		entry["logRecord"] = map[string]interface{}{
+			"call_id":                      callID,
+			"time_based_secure_sharing_id": s.numericID(),
+			"page_viewer_ip":               s.ipAddress(),
+		}
+	case "ExternallySharedCallPlay":
+		record := s.playRecord(callID, eventTime, s.intn(100))
+		record["time_based_secure_sharing_id"] = s.numericID()
+		// the externally shared variant reports the sequence number as a string
+		record["sequence_num"] = fmt.Sprint(record["sequence_num"])
+		entry["logRecord"] = record
+	}
+
+	return entry
+}
+
+func (s *Server) playRecord(callID string, eventTime time.Time, sequence int) map[string]interface{} {
+	start := float64(s.intn(1800))
+
+	return map[string]interface{}{
+		"call_id":                  callID,
+		"video_player_instance_id": s.requestID(),
+		"sequence_num":             sequence,
+		"play_speed":               []float64{1, 1.25, 1.5, 2}[s.intn(4)],
+		"device":                   devices[s.intn(len(devices))],
+		"start_time":               start,
+		"end_time":                 start + float64(1+s.intn(120)),
+		"event_time_on_device":     eventTime,
+		"offline":                  false,
+		"live":                     false,
+	}
+}
+
+func (s *Server) randomCallID() string {
+	if len(s.calls) == 0 {
+		return s.numericID()
+	}
+
+	return s.calls[s.intn(len(s.calls))]["id"].(string)
+}
+
+func (s *Server) timeInWindow(now time.Time) time.Time {
+	return now.Add(-time.Duration(s.int63() % int64(s.opts.Window))).Truncate(time.Second)
+}
+
+func (s *Server) numericID() string {
+	return fmt.Sprintf("%019d", s.int63())
+}
+
+func (s *Server) ipAddress() string {
+	return fmt.Sprintf("203.0.113.%d", 1+s.intn(254))
+}
@@ -0,0 +1,858 @@
+package mock
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	iso8601Format = "2006-01-02T15:04:05Z"
+
+	defaultPageSize = 100
+	defaultWindow   = time.Hour * 24
+
+	noRecordsMessage = "No log records found corresponding to the provided log type and time range"
+)
+
+// Options configures the volume of generated data and the errors injected by the mock.
+type Options struct {
+	AccessKey    string
+	AccessSecret string
+
+	// Calls is the number of calls served by /v2/calls.
+	Calls int
+	// Users is the size of the user pool referenced by calls and log entries.
+	Users int
+	// LogEntries is the number of entries generated per log type.
+	LogEntries int
+	// PageSize is the maximum number of records returned per page.
+	PageSize int
+	// Window is how far back generated events and calls are spread.
+	Window time.Duration
+
+	// RateLimitRate is the probability (0-1) that a request is answered with a 429.
+	RateLimitRate float64
+	// ServerErrorRate is the probability (0-1) that a request is answered with a 500.
+	ServerErrorRate float64
+	// EmptyLogTypes always answer with Gong's "no log records found" error.
+	EmptyLogTypes []string
+
+	Seed int64
+}
+
+type user struct {
+	ID       string
+	Email    string
+	FullName string
+	Title    string
+	Active   bool
+	Created  time.Time
+	Settings map[string]bool
+	History  []map[string]interface{}
+}
+
+type profile struct {
+	WorkspaceID string
+	Definition  map[string]interface{}
+	UserIDs     []string
+}
+
+type folder struct {
+	WorkspaceID string
+	Definition  map[string]interface{}
+	Calls       []map[string]interface{}
+}
+
+// Server is an http.Handler mimicking the subset of the Gong API used by gong2sentinel.
+type Server struct {
+	opts   Options
+	logger *logrus.Entry
+
+	users      []user
+	workspaces []map[string]interface{}
+	profiles   []profile
+	folders    []folder
+	trackers   []map[string]interface{}
+	scorecards []map[string]interface{}
+	// integrations are the CRM integrations, they are not generated randomly
+	integrations []map[string]interface{}
+	calls        []map[string]interface{}
+	// parties holds the participants of every call by call ID
+	parties map[string][]map[string]interface{}
+	logs    map[string][]map[string]interface{}
+	empty   map[string]bool
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+
+	mux *http.ServeMux
+}
+
+func New(logger *logrus.Logger, opts Options) *Server {
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultPageSize
+	}
+	if opts.Window <= 0 {
+		opts.Window = defaultWindow
+	}
+	if opts.Users <= 0 {
+		opts.Users = 1
+	}
+
+	s := &Server{
+		opts:    opts,
+		logger:  logger.WithField("module", "gong_mock"),
+		logs:    map[string][]map[string]interface{}{},
+		parties: map[string][]map[string]interface{}{},
+		empty:   map[string]bool{},
+		rand:    rand.New(rand.NewSource(opts.Seed)),
+		mux:     http.NewServeMux(),
+	}
+
+	for _, logType := range opts.EmptyLogTypes {
+		s.empty[logType] = true
+	}
+
+	s.generate()
+
+	s.mux.HandleFunc("/v2/logs", s.handleLogs)
+	s.mux.HandleFunc("/v2/calls", s.handleCalls)
+	s.mux.HandleFunc("/v2/calls/users-access", s.handleUsersAccess)
+	s.mux.HandleFunc("/v2/calls/extensive", s.handleCallsExtensive)
+	s.mux.HandleFunc("/v2/users/extensive", s.handleUsersExtensive)
+	s.mux.HandleFunc("/v2/users/{id}/settings-history", s.handleSettingsHistory)
+	s.mux.HandleFunc("/v2/workspaces", s.handleWorkspaces)
+	s.mux.HandleFunc("/v2/stats/activity/aggregate-by-period", s.handleActivityByPeriod)
+	s.mux.HandleFunc("/v2/library/folders", s.handleLibraryFolders)
+	s.mux.HandleFunc("/v2/library/folder-content", s.handleLibraryFolderContent)
+	s.mux.HandleFunc("/v2/all-permission-profiles", s.handlePermissionProfiles)
+	s.mux.HandleFunc("/v2/permission-profile/users", s.handlePermissionProfileUsers)
+	s.mux.HandleFunc("/v2/settings/trackers", s.handleTrackers)
+	s.mux.HandleFunc("/v2/settings/scorecards", s.handleScorecards)
+	s.mux.HandleFunc("/v2/crm/integrations", s.handleCRMIntegrations)
+	s.mux.HandleFunc("/v2/data-privacy/data-for-email-address", s.handleDataForEmailAddress)
+	s.mux.HandleFunc("/v2/data-privacy/data-for-phone-number", s.handleDataForPhoneNumber)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := s.logger.WithField("method", r.Method).WithField("path", r.URL.Path)
+
+	key, secret, ok := r.BasicAuth()
+	if !ok ||
+		subtle.ConstantTimeCompare([]byte(key), []byte(s.opts.AccessKey)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(secret), []byte(s.opts.AccessSecret)) != 1 {
+		logger.Warn("rejecting unauthenticated request")
+		s.writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if s.chance(s.opts.RateLimitRate) {
+		logger.Debug("injecting rate limit error")
+		w.Header().Set("Retry-After", "1")
+		s.writeError(w, http.StatusTooManyRequests, "API request limit reached")
+		return
+	}
+
+	if s.chance(s.opts.ServerErrorRate) {
+		logger.Debug("injecting server error")
+		s.writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	logger.Debug("serving request")
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	logType := r.URL.Query().Get("logType")
+	entries, ok := s.logs[logType]
+	if !ok {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid log type: %s", logType))
+		return
+	}
+
+	from, to, err := parseRange(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var filtered []map[string]interface{}
+	if !s.empty[logType] {
+		for _, entry := range entries {
+			eventTime := entry["eventTime"].(time.Time)
+			if !eventTime.Before(from) && eventTime.Before(to) {
+				filtered = append(filtered, entry)
+			}
+		}
+	}
+
+	if len(filtered) == 0 {
+		s.writeError(w, http.StatusNotFound, noRecordsMessage)
+		return
+	}
+
+	page, records, err := s.paginate(r.URL.Query().Get("cursor"), len(filtered))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId":  s.requestID(),
+		"records":    records,
+		"logEntries": filtered[page.start:page.end],
+	})
+}
+
+func (s *Server) handleCalls(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	filtered := s.calls
+	if r.URL.Query().Get("fromDateTime") != "" {
+		from, to, err := parseRange(r)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		filtered = nil
+		for _, call := range s.calls {
+			started, _ := time.Parse(iso8601Format, call["started"].(string))
+			if !started.Before(from) && started.Before(to) {
+				filtered = append(filtered, call)
+			}
+		}
+	}
+
+	if len(filtered) == 0 {
+		s.writeError(w, http.StatusNotFound, "No calls found corresponding to the provided filters")
+		return
+	}
+
+	page, records, err := s.paginate(r.URL.Query().Get("cursor"), len(filtered))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId": s.requestID(),
+		"records":   records,
+		"calls":     filtered[page.start:page.end],
+	})
+}
+
+func (s *Server) handleUsersAccess(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var body struct {
+		Cursor string `json:"cursor"`
+		Filter struct {
+			CallIds []string `json:"callIds"`
+		} `json:"filter"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Malformed request body")
+		return
+	}
+
+	known := map[string]bool{}
+	for _, call := range s.calls {
+		known[call["id"].(string)] = true
+	}
+
+	var accessList []map[string]interface{}
+	for _, callID := range body.Filter.CallIds {
+		if !known[callID] {
+			continue
+		}
+
+		var users []map[string]interface{}
+		for i := 0; i < 1+s.intn(3); i++ {
+			u := s.users[s.intn(len(s.users))]
+			users = append(users, map[string]interface{}{
+				"userId":       u.ID,
+				"emailAddress": u.Email,
+				"accessReason": []string{"Participant", "Manager", "Shared"}[s.intn(3)],
+			})
+		}
+
+		accessList = append(accessList, map[string]interface{}{
+			"callId": callID,
+			"users":  users,
+		})
+	}
+
+	if len(accessList) == 0 {
+		s.writeError(w, http.StatusNotFound, "No calls found corresponding to the provided filters")
+		return
+	}
+
+	page, records, err := s.paginate(body.Cursor, len(accessList))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId":      s.requestID(),
+		"records":        records,
+		"callAccessList": accessList[page.start:page.end],
+	})
+}
+
+func (s *Server) handleCallsExtensive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var body struct {
+		Cursor string `json:"cursor"`
+		Filter struct {
+			FromDateTime string `json:"fromDateTime"`
+			ToDateTime   string `json:"toDateTime"`
+		} `json:"filter"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Malformed request body")
+		return
+	}
+
+	from, _ := time.Parse(time.RFC3339, body.Filter.FromDateTime)
+	to := time.Now().UTC()
+	if body.Filter.ToDateTime != "" {
+		to, _ = time.Parse(time.RFC3339, body.Filter.ToDateTime)
+	}
+
+	var filtered []map[string]interface{}
+	for _, call := range s.calls {
+		started, _ := time.Parse(iso8601Format, call["started"].(string))
+		if started.Before(from) || !started.Before(to) {
+			continue
+		}
+
+		filtered = append(filtered, map[string]interface{}{
+			"metaData": call,
+			"parties":  s.parties[call["id"].(string)],
+			"context": []map[string]interface{}{{
+				"system": "Salesforce",
+				"objects": []map[string]interface{}{{
+					"objectType": "Opportunity",
+					"objectId":   "006" + call["id"].(string)[:12],
+				}},
+			}},
+		})
+	}
+
+	if len(filtered) == 0 {
+		s.writeError(w, http.StatusNotFound, "No calls found corresponding to the provided filters")
+		return
+	}
+
+	page, records, err := s.paginate(body.Cursor, len(filtered))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId": s.requestID(),
+		"records":   records,
+		"calls":     filtered[page.start:page.end],
+	})
+}
+
+func (s *Server) handleUsersExtensive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var body struct {
+		Cursor string `json:"cursor"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Malformed request body")
+		return
+	}
+
+	page, records, err := s.paginate(body.Cursor, len(s.users))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var users []map[string]interface{}
+	for i, u := range s.users[page.start:page.end] {
+		managerID := ""
+		if page.start+i > 0 {
+			managerID = s.users[0].ID
+		}
+
+		users = append(users, map[string]interface{}{
+			"id":                  u.ID,
+			"emailAddress":        u.Email,
+			"created":             u.Created.Format(iso8601Format),
+			"active":              u.Active,
+			"emailAliases":        []string{},
+			"trustedEmailAddress": u.Email,
+			"firstName":           strings.SplitN(u.FullName, " ", 2)[0],
+			"lastName":            strings.SplitN(u.FullName, " ", 2)[1],
+			"title":               u.Title,
+			"phoneNumber":         "",
+			"managerId":           managerID,
+			"settings":            u.Settings,
+			"spokenLanguages":     []map[string]interface{}{{"language": "en-US", "primary": true}},
+		})
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId": s.requestID(),
+		"records":   records,
+		"users":     users,
+	})
+}
+
+func (s *Server) handleSettingsHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	for _, u := range s.users {
+		if u.ID != r.PathValue("id") {
+			continue
+		}
+
+		s.writeJSON(w, map[string]interface{}{
+			"requestId":           s.requestID(),
+			"userSettingsHistory": u.History,
+		})
+		return
+	}
+
+	s.writeError(w, http.StatusNotFound, "User not found")
+}
+
+func (s *Server) handleWorkspaces(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId":  s.requestID(),
+		"workspaces": s.workspaces,
+	})
+}
+
+func (s *Server) handlePermissionProfiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	workspaceID := r.URL.Query().Get("workspaceId")
+	if workspaceID == "" {
+		s.writeError(w, http.StatusBadRequest, "workspaceId is required")
+		return
+	}
+
+	profiles := []map[string]interface{}{}
+	for _, p := range s.profiles {
+		if p.WorkspaceID == workspaceID {
+			profiles = append(profiles, p.Definition)
+		}
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId": s.requestID(),
+		"profiles":  profiles,
+	})
+}
+
+func (s *Server) handlePermissionProfileUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	profileID := r.URL.Query().Get("profileId")
+	for _, p := range s.profiles {
+		if p.Definition["id"] != profileID {
+			continue
+		}
+
+		users := []map[string]interface{}{}
+		for _, userID := range p.UserIDs {
+			for _, u := range s.users {
+				if u.ID == userID {
+					users = append(users, map[string]interface{}{
+						"id":           u.ID,
+						"emailAddress": u.Email,
+						"firstName":    strings.SplitN(u.FullName, " ", 2)[0],
+						"lastName":     strings.SplitN(u.FullName, " ", 2)[1],
+					})
+				}
+			}
+		}
+
+		s.writeJSON(w, map[string]interface{}{
+			"requestId": s.requestID(),
+			"users":     users,
+		})
+		return
+	}
+
+	s.writeError(w, http.StatusNotFound, "Permission profile not found")
+}
+
+func (s *Server) handleLibraryFolders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	workspaceID := r.URL.Query().Get("workspaceId")
+
+	folders := []map[string]interface{}{}
+	for _, f := range s.folders {
+		if workspaceID == "" || f.WorkspaceID == workspaceID {
+			folders = append(folders, f.Definition)
+		}
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId": s.requestID(),
+		"folders":   folders,
+	})
+}
+
+func (s *Server) handleLibraryFolderContent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	folderID := r.URL.Query().Get("folderId")
+	for _, f := range s.folders {
+		if f.Definition["id"] != folderID {
+			continue
+		}
+
+		s.writeJSON(w, map[string]interface{}{
+			"requestId": s.requestID(),
+			"id":        folderID,
+			"name":      f.Definition["name"],
+			"createdBy": f.Definition["createdBy"],
+			"updated":   f.Definition["updated"],
+			"calls":     f.Calls,
+		})
+		return
+	}
+
+	s.writeError(w, http.StatusNotFound, "Folder not found")
+}
+
+func (s *Server) handleActivityByPeriod(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var body struct {
+		Cursor string `json:"cursor"`
+		Filter struct {
+			FromDate string `json:"fromDate"`
+			ToDate   string `json:"toDate"`
+		} `json:"filter"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Malformed request body")
+		return
+	}
+
+	from, fromErr := time.Parse("2006-01-02", body.Filter.FromDate)
+	to, toErr := time.Parse("2006-01-02", body.Filter.ToDate)
+	if fromErr != nil || toErr != nil || !from.Before(to) {
+		s.writeError(w, http.StatusBadRequest, "Invalid date range")
+		return
+	}
+
+	page, records, err := s.paginate(body.Cursor, len(s.users))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var usersActivity []map[string]interface{}
+	for _, u := range s.users[page.start:page.end] {
+		var periods []map[string]interface{}
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			periods = append(periods, map[string]interface{}{
+				"fromDate":              day.Format("2006-01-02"),
+				"toDate":                day.AddDate(0, 0, 1).Format("2006-01-02"),
+				"callsAsHost":           s.intn(6),
+				"callsAttended":         s.intn(8),
+				"ownCallsListenedTo":    s.intn(4),
+				"othersCallsListenedTo": s.intn(10),
+				"callsSharedInternally": s.intn(3),
+				"callsSharedExternally": s.intn(2),
+				"callsCommentsGiven":    s.intn(5),
+				"callsCommentsReceived": s.intn(5),
+			})
+		}
+
+		usersActivity = append(usersActivity, map[string]interface{}{
+			"userId":           u.ID,
+			"userEmailAddress": u.Email,
+			"activityByPeriod": periods,
+		})
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId":     s.requestID(),
+		"records":       records,
+		"usersActivity": usersActivity,
+	})
+}
+
+func (s *Server) handleTrackers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId":       s.requestID(),
+		"keywordTrackers": filterWorkspace(s.trackers, r.URL.Query().Get("workspaceId")),
+	})
+}
+
+func (s *Server) handleScorecards(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId":  s.requestID(),
+		"scorecards": filterWorkspace(s.scorecards, r.URL.Query().Get("workspaceId")),
+	})
+}
+
+func (s *Server) handleCRMIntegrations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId":    s.requestID(),
+		"integrations": s.integrations,
+	})
+}
+
+// filterWorkspace returns the objects of a workspace, or all of them when no workspace is given.
+func filterWorkspace(objects []map[string]interface{}, workspaceID string) []map[string]interface{} {
+	filtered := []map[string]interface{}{}
+	for _, object := range objects {
+		if workspaceID == "" || object["workspaceId"] == workspaceID {
+			filtered = append(filtered, object)
+		}
+	}
+
+	return filtered
+}
+
+func (s *Server) handleDataForEmailAddress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	emailAddress := r.URL.Query().Get("emailAddress")
+	if emailAddress == "" {
+		s.writeError(w, http.StatusBadRequest, "emailAddress is required")
+		return
+	}
+
+	// only calls are referenced, the mock does not generate emails or meetings
+	calls := []map[string]interface{}{}
+	for _, call := range s.calls {
+		for _, party := range s.parties[call["id"].(string)] {
+			if strings.EqualFold(party["emailAddress"].(string), emailAddress) {
+				calls = append(calls, map[string]interface{}{
+					"id":     call["id"],
+					"status": "COMPLETED",
+				})
+				break
+			}
+		}
+	}
+
+	s.writeJSON(w, map[string]interface{}{
+		"requestId":          s.requestID(),
+		"calls":              calls,
+		"emails":             []interface{}{},
+		"meetings":           []interface{}{},
+		"customerData":       []interface{}{},
+		"customerEngagement": []interface{}{},
+	})
+}
+
+func (s *Server) handleDataForPhoneNumber(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	phoneNumber := r.URL.Query().Get("phoneNumber")
+	if phoneNumber == "" {
+		s.writeError(w, http.StatusBadRequest, "phoneNumber is required")
+		return
+	}
+
+	// generated parties have no phone numbers so nothing ever matches
+	s.writeJSON(w, map[string]interface{}{
+		"requestId":            s.requestID(),
+		"suppliedPhoneNumber":  phoneNumber,
+		"matchingPhoneNumbers": []string{},
+		"emailAddresses":       []string{},
+		"calls":                []interface{}{},
+		"emails":               []interface{}{},
+		"meetings":             []interface{}{},
+		"customerData":         []interface{}{},
+		"customerEngagement":   []interface{}{},
+	})
+}
+
+type pageBounds struct {
+	start int
+	end   int
+}
+
+func (s *Server) paginate(cursor string, total int) (pageBounds, map[string]interface{}, error) {
+	offset := 0
+	if cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return pageBounds{}, nil, fmt.Errorf("Invalid cursor: %s", cursor)
+		}
+
+		offset, err = strconv.Atoi(string(decoded))
+		if err != nil || offset < 0 || offset > total {
+			return pageBounds{}, nil, fmt.Errorf("Invalid cursor: %s", cursor)
+		}
+	}
+
+	end := offset + s.opts.PageSize
+	if end > total {
+		end = total
+	}
+
+	records := map[string]interface{}{
+		"totalRecords":      total,
+		"currentPageSize":   end - offset,
+		"currentPageNumber": offset / s.opts.PageSize,
+	}
+	if end < total {
+		records["cursor"] = base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(end)))
+	}
+
+	return pageBounds{start: offset, end: end}, records, nil
+}
+
+func parseRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	fromParam := query.Get("fromDateTime")
+	if fromParam == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("fromDateTime is required")
+	}
+
+	from, err := time.Parse(time.RFC3339, fromParam)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid fromDateTime: %s", fromParam)
+	}
+
+	to := time.Now().UTC()
+	if toParam := query.Get("toDateTime"); toParam != "" {
+		if to, err = time.Parse(time.RFC3339, toParam); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Invalid toDateTime: %s", toParam)
+		}
+	}
+
+	return from, to, nil
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"requestId": s.requestID(),
+		"errors":    []string{msg},
+	})
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		s.logger.WithError(err).Error("failed to write response")
+	}
+}
+
+func (s *Server) requestID() string {
+	return strconv.FormatInt(s.int63(), 36)
+}
+
+func (s *Server) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+
+	s.randMu.Lock()
+	defer s.randMu.Unlock()
+
+	return s.rand.Float64() < p
+}
+
+func (s *Server) intn(n int) int {
+	s.randMu.Lock()
+	defer s.randMu.Unlock()
+
+	return s.rand.Intn(n)
+}
+
+func (s *Server) int63() int64 {
+	s.randMu.Lock()
+	defer s.randMu.Unlock()
+
+	return s.rand.Int63()
+}
@@ -0,0 +1,160 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func newTestServer(t *testing.T, opts Options) *httptest.Server {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	opts.AccessKey = "key"
+	opts.AccessSecret = "secret"
+
+	server := httptest.NewServer(New(logger, opts))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+type pageResponse struct {
+	Records struct {
+		Cursor string `json:"cursor"`
+	} `json:"records"`
+	Calls []struct {
+		ID string `json:"id"`
+	} `json:"calls"`
+	CallAccessList []struct {
+		CallID string `json:"callId"`
+	} `json:"callAccessList"`
+}
+
+func do(t *testing.T, server *httptest.Server, method, path string, body interface{}, auth bool) (*http.Response, pageResponse) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth {
+		req.SetBasicAuth("key", "secret")
+	}
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var page pageResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return resp, page
+}
+
+func TestPagination(t *testing.T) {
+	server := newTestServer(t, Options{Seed: 1, Calls: 25, Users: 5, PageSize: 10})
+
+	var callIDs []string
+	seen := map[string]bool{}
+	query := url.Values{}
+	for pages := 1; ; pages++ {
+		resp, page := do(t, server, http.MethodGet, "/v2/calls?"+query.Encode(), nil, true)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("calls page %d: got status %d", pages, resp.StatusCode)
+		}
+
+		for _, call := range page.Calls {
+			if seen[call.ID] {
+				t.Fatalf("call %s served twice", call.ID)
+			}
+			seen[call.ID] = true
+			callIDs = append(callIDs, call.ID)
+		}
+
+		if page.Records.Cursor == "" {
+			if pages != 3 {
+				t.Errorf("got %d calls pages, want 3", pages)
+			}
+			break
+		}
+		query.Set("cursor", page.Records.Cursor)
+	}
+
+	if len(callIDs) != 25 {
+		t.Fatalf("got %d calls, want 25", len(callIDs))
+	}
+
+	body := map[string]interface{}{"filter": map[string]interface{}{"callIds": callIDs}}
+	access := 0
+	for {
+		resp, page := do(t, server, http.MethodPost, "/v2/calls/users-access", body, true)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("users-access: got status %d", resp.StatusCode)
+		}
+
+		access += len(page.CallAccessList)
+
+		if page.Records.Cursor == "" {
+			break
+		}
+		body["cursor"] = page.Records.Cursor
+	}
+
+	if access != 25 {
+		t.Errorf("got %d call access entries, want 25", access)
+	}
+}
+
+func TestErrorInjection(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           Options
+		auth           bool
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{name: "no injection", auth: true, wantStatus: http.StatusOK},
+		{name: "unauthenticated", auth: false, wantStatus: http.StatusUnauthorized},
+		{name: "rate limited", opts: Options{RateLimitRate: 1}, auth: true, wantStatus: http.StatusTooManyRequests, wantRetryAfter: "1"},
+		{name: "server error", opts: Options{ServerErrorRate: 1}, auth: true, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Seed = 1
+			tt.opts.Calls = 3
+			server := newTestServer(t, tt.opts)
+
+			resp, _ := do(t, server, http.MethodGet, "/v2/calls", nil, tt.auth)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := resp.Header.Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("got Retry-After %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package permissions
+
+import (
+	"encoding/json"
+	"fmt"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/snapshot"
+	"gong2sentinel/pkg/gong/workspaces"
+	"gong2sentinel/pkg/schema"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	iso8601Format = "2006-01-02T15:04:05Z"
+
+	kindProfile = "permission-profile"
+
+	profilesPath     = "/v2/all-permission-profiles"
+	profileUsersPath = "/v2/permission-profile/users"
+
+	EventProfileCreated  = "profile-created"
+	EventProfileModified = "profile-modified"
+	EventProfileDeleted  = "profile-deleted"
+	EventUserAssigned    = "user-assigned"
+	EventUserRemoved     = "user-removed"
+)
+
+// Profile is the state of a permission profile and its assigned users.
+type Profile struct {
+	Name        string          `json:"name"`
+	WorkspaceID string          `json:"workspaceId"`
+	Definition  json.RawMessage `json:"definition"`
+	// Users maps the assigned user IDs to their email address.
+	Users map[string]string `json:"users"`
+
+	// Source is the API call the profile was returned by.
+	Source gong.Source `json:"-"`
+}
+
+// Record is a GongPermissionProfiles event.
+type Record struct {
+	TimeGenerated   schema.DateTime `json:"TimeGenerated"`
+	Event           string          `json:"event"`
+	ProfileID       string          `json:"profileId"`
+	ProfileName     string          `json:"profileName"`
+	WorkspaceID     string          `json:"workspaceId"`
+	InitialSnapshot bool            `json:"initialSnapshot"`
+	// ChangedFields is a comma separated list of the dotted paths that changed
+	ChangedFields string          `json:"changedFields,omitempty"`
+	OldValue      json.RawMessage `json:"oldValue,omitempty"`
+	NewValue      json.RawMessage `json:"newValue,omitempty"`
+	// UserID and UserEmail are only set on user-assigned and user-removed events
+	UserID    string `json:"userId,omitempty"`
+	UserEmail string `json:"userEmail,omitempty"`
+}
+
+// Snapshot maps permission profile IDs to their state, it is persisted between runs to detect changes.
+type Snapshot map[string]Profile
+
+// items converts the profiles into snapshot items so they are compared like the other snapshots.
+func (s Snapshot) items() snapshot.Snapshot {
+	items := make(snapshot.Snapshot, len(s))
+	for profileID, profile := range s {
+		items[snapshot.Key(kindProfile, profileID)] = snapshot.Item{
+			Kind:        kindProfile,
+			ID:          profileID,
+			Name:        profile.Name,
+			WorkspaceID: profile.WorkspaceID,
+			Definition:  profile.Definition,
+			Source:      profile.Source,
+		}
+	}
+
+	return items
+}
+
+// GetSnapshot fetches all permission profiles of the given workspaces with their assigned users.
+func GetSnapshot(client *gong.Client, allWorkspaces []workspaces.Workspace) (Snapshot, snapshot.Sources, error) {
+	current := Snapshot{}
+	sources := snapshot.Sources{}
+
+	for _, workspace := range allWorkspaces {
+		query := url.Values{}
+		query.Set("workspaceId", workspace.ID)
+
+		var response struct {
+			RequestID string            `json:"requestId"`
+			Profiles  []json.RawMessage `json:"profiles"`
+		}
+		if err := client.Get(profilesPath, query, &response); err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch permission profiles for workspace %s: %v", workspace.ID, err)
+		}
+
+		source := gong.Source{RequestID: response.RequestID, Endpoint: client.URL(profilesPath, query)}
+		sources[snapshot.Scope(kindProfile, workspace.ID)] = source
+		// profiles of a deleted workspace are removed by the workspaces call no longer returning it
+		sources[snapshot.Scope(kindProfile, "")] = workspace.Source
+
+		for _, definition := range response.Profiles {
+			var profile struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(definition, &profile); err != nil {
+				return nil, nil, fmt.Errorf("failed to unmarshal permission profile: %v", err)
+			}
+
+			users, err := getProfileUsers(client, profile.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			current[profile.ID] = Profile{
+				Name:        profile.Name,
+				WorkspaceID: workspace.ID,
+				Definition:  definition,
+				Users:       users,
+				Source:      source,
+			}
+		}
+	}
+
+	return current, sources, nil
+}
+
+func getProfileUsers(client *gong.Client, profileID string) (map[string]string, error) {
+	query := url.Values{}
+	query.Set("profileId", profileID)
+
+	var response struct {
+		Users []struct {
+			ID           string `json:"id"`
+			EmailAddress string `json:"emailAddress"`
+		} `json:"users"`
+	}
+	if err := client.Get(profileUsersPath, query, &response); err != nil {
+		return nil, fmt.Errorf("failed to fetch users of permission profile %s: %v", profileID, err)
+	}
+
+	users := make(map[string]string, len(response.Users))
+	for _, user := range response.Users {
+		users[user.ID] = user.EmailAddress
+	}
+
+	return users, nil
+}
+
+// GetProfileChanges fetches the current permission profiles and returns change events compared to previous.
+// When there is no previous snapshot every profile and assignment is reported as an initial snapshot event.
+// The returned snapshot should only be persisted once the records have been shipped.
+func GetProfileChanges(client *gong.Client, allWorkspaces []workspaces.Workspace, previous Snapshot) ([]map[string]string, Snapshot, error) {
+	current, sources, err := GetSnapshot(client, allWorkspaces)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return Diff(previous, current, sources, time.Now()), current, nil
+}
+
+// profileEvents names the snapshot events of permission profiles.
+var profileEvents = map[string]string{
+	snapshot.EventAdded:    EventProfileCreated,
+	snapshot.EventModified: EventProfileModified,
+	snapshot.EventRemoved:  EventProfileDeleted,
+}
+
+// Diff returns the change events between two snapshots, a nil previous snapshot marks all events as initial.
+// Profiles are compared with snapshot.Compare, user assignments are compared for every current profile and
+// every user of a deleted profile is reported as removed.
+func Diff(previous, current Snapshot, sources snapshot.Sources, now time.Time) []map[string]string {
+	initial := previous == nil
+	timeGenerated := now.UTC().Format(iso8601Format)
+
+	var records []map[string]string
+	addRecord := func(event string, item snapshot.Item, source gong.Source, record Record) {
+		record.TimeGenerated = schema.DateTime(timeGenerated)
+		record.Event = event
+		record.ProfileID = item.ID
+		record.ProfileName = item.Name
+		record.WorkspaceID = item.WorkspaceID
+		record.InitialSnapshot = initial
+
+		// records only have text, boolean and raw JSON columns, which always encode
+		encoded, _ := schema.Encode(record)
+		source.Annotate(encoded)
+
+		records = append(records, encoded)
+	}
+
+	currentItems := current.items()
+	removedSources := map[string]gong.Source{}
+
+	for _, change := range snapshot.Compare(previous.items(), currentItems, sources) {
+		var record Record
+
+		switch change.Event {
+		case snapshot.EventAdded:
+			record.NewValue = change.Item.Definition
+		case snapshot.EventModified:
+			record.ChangedFields = strings.Join(change.ChangedFields, ",")
+			record.OldValue = change.Old.Definition
+			record.NewValue = change.Item.Definition
+		case snapshot.EventRemoved:
+			record.OldValue = change.Item.Definition
+			removedSources[change.Item.ID] = change.Source
+		}
+
+		addRecord(profileEvents[change.Event], change.Item, change.Source, record)
+	}
+
+	for _, profileID := range snapshot.SortedKeys(current) {
+		profile := current[profileID]
+		item := currentItems[snapshot.Key(kindProfile, profileID)]
+		old := previous[profileID]
+
+		for _, userID := range snapshot.SortedKeys(profile.Users) {
+			if _, ok := old.Users[userID]; !ok {
+				addRecord(EventUserAssigned, item, profile.Source, Record{UserID: userID, UserEmail: profile.Users[userID]})
+			}
+		}
+
+		for _, userID := range snapshot.SortedKeys(old.Users) {
+			if _, ok := profile.Users[userID]; !ok {
+				addRecord(EventUserRemoved, item, profile.Source, Record{UserID: userID, UserEmail: old.Users[userID]})
+			}
+		}
+	}
+
+	previousItems := previous.items()
+	for _, profileID := range snapshot.SortedKeys(previous) {
+		if _, ok := current[profileID]; ok {
+			continue
+		}
+
+		old := previous[profileID]
+		item := previousItems[snapshot.Key(kindProfile, profileID)]
+		for _, userID := range snapshot.SortedKeys(old.Users) {
+			addRecord(EventUserRemoved, item, removedSources[profileID], Record{UserID: userID, UserEmail: old.Users[userID]})
+		}
+	}
+
+	return records
+}
@@ -0,0 +1,79 @@
+package permissions
+
+import (
+	"encoding/json"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/snapshot"
+	"strings"
+	"testing"
+	"time"
+)
+
+func profile(definition string, users map[string]string) Profile {
+	return Profile{
+		Name:        "Sales",
+		WorkspaceID: "w1",
+		Definition:  json.RawMessage(definition),
+		Users:       users,
+		Source:      gong.Source{RequestID: "current"},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	sources := snapshot.Sources{snapshot.Scope(kindProfile, "w1"): {RequestID: "listing"}}
+
+	tests := []struct {
+		name        string
+		previous    Snapshot
+		current     Snapshot
+		want        []string
+		wantInitial bool
+	}{
+		{
+			name:        "initial snapshot reports every profile and user",
+			previous:    nil,
+			current:     Snapshot{"p1": profile(`{"a":1}`, map[string]string{"u1": "a@example.com", "u2": "b@example.com"})},
+			want:        []string{"profile-created p1  current", "user-assigned p1 u1 current", "user-assigned p1 u2 current"},
+			wantInitial: true,
+		},
+		{
+			name:     "unchanged profile has no events",
+			previous: Snapshot{"p1": profile(`{"a":1}`, map[string]string{"u1": "a@example.com"})},
+			current:  Snapshot{"p1": profile(`{"a":1}`, map[string]string{"u1": "a@example.com"})},
+		},
+		{
+			name:     "modified definition and reassigned user",
+			previous: Snapshot{"p1": profile(`{"a":1}`, map[string]string{"u1": "a@example.com"})},
+			current:  Snapshot{"p1": profile(`{"a":2}`, map[string]string{"u2": "b@example.com"})},
+			want:     []string{"profile-modified p1  current", "user-assigned p1 u2 current", "user-removed p1 u1 current"},
+		},
+		{
+			name:     "deleted profile removes all its users",
+			previous: Snapshot{"p1": profile(`{"a":1}`, map[string]string{"u1": "a@example.com", "u2": "b@example.com"})},
+			current:  Snapshot{},
+			want:     []string{"profile-deleted p1  listing", "user-removed p1 u1 listing", "user-removed p1 u2 listing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := Diff(tt.previous, tt.current, sources, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+
+			var got []string
+			for _, record := range records {
+				got = append(got, strings.Join([]string{record["event"], record["profileId"], record["userId"], record["requestId"]}, " "))
+
+				if initial := record["initialSnapshot"] == "true"; initial != tt.wantInitial {
+					t.Errorf("%s: got initialSnapshot %v, want %v", record["event"], initial, tt.wantInitial)
+				}
+				if record["workspaceId"] != "w1" || record["profileName"] != "Sales" {
+					t.Errorf("%s: got profile %s in workspace %s", record["event"], record["profileName"], record["workspaceId"])
+				}
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got events\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
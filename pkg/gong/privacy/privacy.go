@@ -0,0 +1,204 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/schema"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	iso8601Format = "2006-01-02T15:04:05Z"
+
+	emailAddressPath = "/v2/data-privacy/data-for-email-address"
+	phoneNumberPath  = "/v2/data-privacy/data-for-phone-number"
+
+	IdentifierEmail = "email"
+	IdentifierPhone = "phone"
+
+	OutcomeAttempted = "attempted"
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+// Call is a call referencing the data subject.
+type Call struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// Email is an email referencing the data subject.
+type Email struct {
+	ID          string `json:"id"`
+	From        string `json:"from"`
+	SentTime    string `json:"sentTime"`
+	Mailbox     string `json:"mailbox"`
+	MessageHash string `json:"messageHash"`
+}
+
+// Meeting is a meeting referencing the data subject.
+type Meeting struct {
+	ID string `json:"id"`
+}
+
+// Result is what Gong holds for a data subject.
+type Result struct {
+	RequestID      string `json:"requestId"`
+	Endpoint       string `json:"endpoint"`
+	IdentifierType string `json:"identifierType"`
+	Identifier     string `json:"identifier"`
+
+	Calls                []Call            `json:"calls"`
+	Emails               []Email           `json:"emails"`
+	Meetings             []Meeting         `json:"meetings"`
+	CustomerData         []json.RawMessage `json:"customerData"`
+	CustomerEngagement   []json.RawMessage `json:"customerEngagement"`
+	MatchingPhoneNumbers []string          `json:"matchingPhoneNumbers,omitempty"`
+	EmailAddresses       []string          `json:"emailAddresses,omitempty"`
+}
+
+// IdentifierType guesses whether the identifier is an email address or a phone number.
+func IdentifierType(identifier string) string {
+	if strings.Contains(identifier, "@") {
+		return IdentifierEmail
+	}
+
+	return IdentifierPhone
+}
+
+// Lookup queries the Gong data privacy endpoints for an email address or phone number.
+func Lookup(client *gong.Client, identifier string) (*Result, error) {
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return nil, fmt.Errorf("no email address or phone number provided")
+	}
+
+	identifierType := IdentifierType(identifier)
+
+	query := url.Values{}
+	path := emailAddressPath
+	if identifierType == IdentifierEmail {
+		query.Set("emailAddress", identifier)
+	} else {
+		path = phoneNumberPath
+		query.Set("phoneNumber", identifier)
+	}
+
+	result := &Result{}
+	if err := client.Get(path, query, result); err != nil {
+		return nil, fmt.Errorf("failed to look up data for %s: %w", identifierType, err)
+	}
+
+	result.Endpoint = client.URL(path, nil)
+	result.IdentifierType = identifierType
+	result.Identifier = identifier
+
+	return result, nil
+}
+
+// Record is a GongPrivacyLookups record, it counts the data found rather than holding it.
+// A lookup is logged twice under the same lookupId, once when attempted and once with its outcome.
+type Record struct {
+	TimeGenerated      schema.DateTime `json:"TimeGenerated"`
+	LookupID           string          `json:"lookupId"`
+	Outcome            string          `json:"outcome"`
+	Error              string          `json:"error"`
+	Requester          string          `json:"requester"`
+	Reason             string          `json:"reason"`
+	IdentifierType     string          `json:"identifierType"`
+	IdentifierHmac     string          `json:"identifierHmac"`
+	Calls              int             `json:"calls"`
+	Emails             int             `json:"emails"`
+	Meetings           int             `json:"meetings"`
+	CustomerData       int             `json:"customerData"`
+	CustomerEngagement int             `json:"customerEngagement"`
+}
+
+// Audit describes a lookup for the records logging it for accountability.
+// The identifier is only stored as an HMAC-SHA256 keyed with HashKey so the audit trail does not leak the
+// data subject: a plain hash of an email address or phone number is reversed with a dictionary of candidates.
+type Audit struct {
+	LookupID   string
+	Requester  string
+	Reason     string
+	Identifier string
+	HashKey    []byte
+}
+
+func (a Audit) record(outcome string, now time.Time) Record {
+	identifier := strings.TrimSpace(a.Identifier)
+	mac := hmac.New(sha256.New, a.HashKey)
+	mac.Write([]byte(strings.ToLower(identifier)))
+
+	return Record{
+		TimeGenerated:  schema.DateTime(now.UTC().Format(iso8601Format)),
+		LookupID:       a.LookupID,
+		Outcome:        outcome,
+		Requester:      a.Requester,
+		Reason:         a.Reason,
+		IdentifierType: IdentifierType(identifier),
+		IdentifierHmac: hex.EncodeToString(mac.Sum(nil)),
+	}
+}
+
+// AttemptRecord returns the record logged before the lookup is performed.
+func (a Audit) AttemptRecord(now time.Time) map[string]string {
+	// the record only has text and number columns, which always encode
+	record, _ := schema.Encode(a.record(OutcomeAttempted, now))
+
+	return record
+}
+
+// FailureRecord returns the record logged when the lookup failed.
+func (a Audit) FailureRecord(err error, now time.Time) map[string]string {
+	failure := a.record(OutcomeFailed, now)
+	failure.Error = a.failureMessage(err)
+
+	record, _ := schema.Encode(failure)
+
+	return record
+}
+
+// urlQuery matches the query string of the URLs quoted by transport errors.
+var urlQuery = regexp.MustCompile(`\?[^\s"]*`)
+
+// failureMessage describes why a lookup failed without the identifier. Gong errors are reduced to their status
+// and messages, other errors quote the request URL and lose every query string.
+func (a Audit) failureMessage(err error) string {
+	message := urlQuery.ReplaceAllString(err.Error(), "")
+
+	var apiErr *gong.APIError
+	if errors.As(err, &apiErr) {
+		message = apiErr.Error()
+	}
+
+	// Gong may echo the identifier in its messages
+	if identifier := strings.TrimSpace(a.Identifier); identifier != "" {
+		message = strings.ReplaceAll(message, identifier, "[redacted]")
+	}
+
+	return message
+}
+
+// SuccessRecord returns the record logged with the result counts of the lookup.
+func (a Audit) SuccessRecord(r *Result, now time.Time) map[string]string {
+	success := a.record(OutcomeSucceeded, now)
+	success.Calls = len(r.Calls)
+	success.Emails = len(r.Emails)
+	success.Meetings = len(r.Meetings)
+	success.CustomerData = len(r.CustomerData)
+	success.CustomerEngagement = len(r.CustomerEngagement)
+
+	record, _ := schema.Encode(success)
+	gong.Source{RequestID: r.RequestID, Endpoint: r.Endpoint}.Annotate(record)
+
+	return record
+}
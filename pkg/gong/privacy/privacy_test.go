@@ -0,0 +1,81 @@
+package privacy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"gong2sentinel/pkg/gong"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFailureRecordOmitsIdentifier(t *testing.T) {
+	const identifier = "jane.doe@example.com"
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	echoing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"requestId":"r1","errors":["Unknown email address ` + identifier + `"]}`))
+	}))
+	defer echoing.Close()
+
+	tests := []struct {
+		name      string
+		baseURL   string
+		wantError string
+	}{
+		{name: "failing transport", baseURL: closed.URL, wantError: "failed to send HTTP request"},
+		{name: "gong error echoing the identifier", baseURL: echoing.URL, wantError: "gong api returned status 400: Unknown email address [redacted]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Lookup(gong.New(tt.baseURL, "key", "secret"), identifier)
+			if err == nil {
+				t.Fatal("expected the lookup to fail")
+			}
+
+			audit := Audit{LookupID: "l1", Identifier: identifier, HashKey: []byte("key")}
+			record := audit.FailureRecord(err, time.Now())
+
+			for column, value := range record {
+				for _, form := range []string{identifier, url.QueryEscape(identifier)} {
+					if strings.Contains(value, form) {
+						t.Errorf("column %s holds the identifier: %s", column, value)
+					}
+				}
+			}
+
+			if !strings.Contains(record["error"], tt.wantError) {
+				t.Errorf("got error %q, want it to contain %q", record["error"], tt.wantError)
+			}
+			if record["outcome"] != OutcomeFailed {
+				t.Errorf("got outcome %s, want %s", record["outcome"], OutcomeFailed)
+			}
+		})
+	}
+}
+
+func TestIdentifierHmac(t *testing.T) {
+	now := time.Now()
+	hmacOf := func(identifier, key string) string {
+		return Audit{Identifier: identifier, HashKey: []byte(key)}.AttemptRecord(now)["identifierHmac"]
+	}
+
+	if hmacOf("Jane.Doe@example.com ", "k1") != hmacOf("jane.doe@example.com", "k1") {
+		t.Error("identifiers differing in case or whitespace should have the same HMAC")
+	}
+	if hmacOf("jane.doe@example.com", "k1") == hmacOf("jane.doe@example.com", "k2") {
+		t.Error("different keys should yield different HMACs")
+	}
+
+	plain := sha256.Sum256([]byte("jane.doe@example.com"))
+	if hmacOf("jane.doe@example.com", "k1") == hex.EncodeToString(plain[:]) {
+		t.Error("the HMAC should not be the plain SHA-256 of the identifier")
+	}
+}
@@ -0,0 +1,99 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/snapshot"
+	"time"
+)
+
+// endpoint describes a Gong configuration endpoint returning a list of objects.
+type endpoint struct {
+	kind    string
+	path    string
+	listKey string
+	idKey   string
+	nameKey string
+}
+
+// endpoints are the configuration endpoints covered by the settings snapshot,
+// adding another list endpoint only takes a new entry here.
+var endpoints = []endpoint{
+	{kind: "tracker", path: "/v2/settings/trackers", listKey: "keywordTrackers", idKey: "trackerId", nameKey: "trackerName"},
+	{kind: "scorecard", path: "/v2/settings/scorecards", listKey: "scorecards", idKey: "scorecardId", nameKey: "scorecardName"},
+}
+
+// GetSnapshot fetches all configuration objects of every endpoint and normalizes them.
+func GetSnapshot(client *gong.Client) (snapshot.Snapshot, snapshot.Sources, error) {
+	current := snapshot.Snapshot{}
+	sources := snapshot.Sources{}
+
+	for _, e := range endpoints {
+		var response map[string]json.RawMessage
+		if err := client.Get(e.path, nil, &response); err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch %s settings: %v", e.kind, err)
+		}
+
+		var requestID string
+		_ = json.Unmarshal(response["requestId"], &requestID)
+		source := gong.Source{RequestID: requestID, Endpoint: client.URL(e.path, nil)}
+		sources[snapshot.Scope(e.kind, "")] = source
+
+		var objects []json.RawMessage
+		if list, ok := response[e.listKey]; ok {
+			if err := json.Unmarshal(list, &objects); err != nil {
+				return nil, nil, fmt.Errorf("failed to unmarshal %s settings: %v", e.kind, err)
+			}
+		}
+
+		for _, object := range objects {
+			item, err := newItem(e, object)
+			if err != nil {
+				return nil, nil, err
+			}
+			item.Source = source
+
+			current[snapshot.Key(item.Kind, item.ID)] = item
+		}
+	}
+
+	return current, sources, nil
+}
+
+func newItem(e endpoint, object json.RawMessage) (snapshot.Item, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(object, &fields); err != nil {
+		return snapshot.Item{}, fmt.Errorf("failed to unmarshal %s: %v", e.kind, err)
+	}
+
+	definition, err := snapshot.Normalize(object)
+	if err != nil {
+		return snapshot.Item{}, fmt.Errorf("failed to normalize %s: %v", e.kind, err)
+	}
+
+	item := snapshot.Item{
+		Kind:       e.kind,
+		Definition: definition,
+	}
+	item.ID, _ = fields[e.idKey].(string)
+	item.Name, _ = fields[e.nameKey].(string)
+	item.WorkspaceID, _ = fields["workspaceId"].(string)
+
+	if item.ID == "" {
+		return snapshot.Item{}, fmt.Errorf("%s without %s", e.kind, e.idKey)
+	}
+
+	return item, nil
+}
+
+// GetSettingsChanges fetches the current trackers and scorecards and returns change events compared to previous.
+// The returned snapshot should only be persisted once the records have been shipped.
+func GetSettingsChanges(client *gong.Client, previous snapshot.Snapshot) ([]map[string]string, snapshot.Snapshot, error) {
+	current, sources, err := GetSnapshot(client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return snapshot.Diff(previous, current, sources, time.Now()), current, nil
+}
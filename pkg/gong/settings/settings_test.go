@@ -0,0 +1,117 @@
+package settings
+
+import (
+	"encoding/json"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/snapshot"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// settingsServer answers the trackers and scorecards endpoints with the given responses.
+func settingsServer(t *testing.T, trackers, scorecards string) *gong.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/settings/trackers":
+			_, _ = w.Write([]byte(trackers))
+		case "/v2/settings/scorecards":
+			_, _ = w.Write([]byte(scorecards))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := gong.New(server.URL, "key", "secret")
+	client.SetRateLimit(-1)
+
+	return client
+}
+
+func TestGetSettingsChanges(t *testing.T) {
+	trackers := `{"requestId":"r-trackers","keywordTrackers":[
+		{"trackerId":"t1","trackerName":"Pricing","workspaceId":"w1","languageKeywords":[{"keywords":["price"]}]}]}`
+	scorecards := `{"requestId":"r-scorecards","scorecards":[{"scorecardId":"s1","scorecardName":"Discovery","enabled":true}]}`
+
+	initial, _, err := GetSnapshot(settingsServer(t, trackers, scorecards))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		previous   snapshot.Snapshot
+		trackers   string
+		scorecards string
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:       "initial snapshot",
+			trackers:   trackers,
+			scorecards: scorecards,
+			want:       []string{"added scorecard s1 Discovery r-scorecards", "added tracker t1 Pricing r-trackers"},
+		},
+		{
+			name:       "unchanged regardless of key order",
+			previous:   initial,
+			trackers:   `{"requestId":"r2","keywordTrackers":[{"workspaceId":"w1","languageKeywords":[{"keywords":["price"]}],"trackerName":"Pricing","trackerId":"t1"}]}`,
+			scorecards: scorecards,
+		},
+		{
+			name:       "modified tracker and removed scorecard",
+			previous:   initial,
+			trackers:   `{"requestId":"r2","keywordTrackers":[{"trackerId":"t1","trackerName":"Pricing","workspaceId":"w1","languageKeywords":[{"keywords":["cost"]}]}]}`,
+			scorecards: `{"requestId":"r3","scorecards":[]}`,
+			want:       []string{"modified tracker t1 Pricing r2", "removed scorecard s1 Discovery r3"},
+		},
+		{
+			name:       "missing list is an empty list",
+			previous:   initial,
+			trackers:   trackers,
+			scorecards: `{"requestId":"r3"}`,
+			want:       []string{"removed scorecard s1 Discovery r3"},
+		},
+		{
+			name:       "object without ID",
+			trackers:   `{"keywordTrackers":[{"trackerName":"Pricing"}]}`,
+			scorecards: scorecards,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, _, err := GetSettingsChanges(settingsServer(t, tt.trackers, tt.scorecards), tt.previous)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSettingsChanges() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, record := range records {
+				got = append(got, strings.Join([]string{record["event"], record["kind"], record["itemId"], record["itemName"], record["requestId"]}, " "))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got events\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestNewItemNormalizes(t *testing.T) {
+	item, err := newItem(endpoints[0], json.RawMessage(`{"workspaceId":"w1","trackerName":"Pricing","trackerId":"t1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item.Kind != "tracker" || item.ID != "t1" || item.Name != "Pricing" || item.WorkspaceID != "w1" {
+		t.Errorf("got item %+v", item)
+	}
+	if string(item.Definition) != `{"trackerId":"t1","trackerName":"Pricing","workspaceId":"w1"}` {
+		t.Errorf("got definition %s, want sorted keys", item.Definition)
+	}
+}
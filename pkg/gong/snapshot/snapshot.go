@@ -0,0 +1,225 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/schema"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	iso8601Format = "2006-01-02T15:04:05Z"
+
+	EventAdded    = "added"
+	EventRemoved  = "removed"
+	EventModified = "modified"
+)
+
+// Item is the normalized state of a single configuration object such as a tracker or scorecard.
+type Item struct {
+	Kind        string          `json:"kind"`
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	WorkspaceID string          `json:"workspaceId"`
+	Definition  json.RawMessage `json:"definition"`
+
+	// Source is the API call the item was returned by.
+	Source gong.Source `json:"-"`
+}
+
+// Record is a change event of a snapshot item.
+type Record struct {
+	TimeGenerated   schema.DateTime `json:"TimeGenerated"`
+	Event           string          `json:"event"`
+	Kind            string          `json:"kind"`
+	ItemID          string          `json:"itemId"`
+	ItemName        string          `json:"itemName"`
+	WorkspaceID     string          `json:"workspaceId"`
+	InitialSnapshot bool            `json:"initialSnapshot"`
+	// ChangedFields is a comma separated list of the dotted paths that changed
+	ChangedFields string          `json:"changedFields,omitempty"`
+	OldValue      json.RawMessage `json:"oldValue,omitempty"`
+	NewValue      json.RawMessage `json:"newValue,omitempty"`
+}
+
+// Snapshot maps item keys to their state, it is persisted between runs to detect changes.
+type Snapshot map[string]Item
+
+// Key returns the snapshot key of an item, IDs are only unique per kind.
+func Key(kind, id string) string {
+	return kind + "/" + id
+}
+
+// Normalize re-encodes a JSON document with sorted keys so equal configurations compare byte for byte.
+func Normalize(data json.RawMessage) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON document: %v", err)
+	}
+
+	normalized, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON document: %v", err)
+	}
+
+	return normalized, nil
+}
+
+// Sources maps the listings of the current run, see Scope, to the API call that returned them.
+// Removed items are traced back to the call that no longer returned them, persisted items do not keep their source.
+type Sources map[string]gong.Source
+
+// Scope identifies the listing items are returned by, workspaceID is empty for listings covering all workspaces.
+func Scope(kind, workspaceID string) string {
+	return kind + "@" + workspaceID
+}
+
+// lookup returns the source of the listing covering item.
+func (s Sources) lookup(item Item) gong.Source {
+	if source, ok := s[Scope(item.Kind, item.WorkspaceID)]; ok {
+		return source
+	}
+
+	return s[Scope(item.Kind, "")]
+}
+
+// Change is an item added, removed or modified between two snapshots.
+type Change struct {
+	Event string
+	// Item is the current state of the item, or its last known state when it was removed.
+	Item Item
+	// Old is the previous state of a modified item.
+	Old           Item
+	ChangedFields []string
+	// Source is the API call the change was detected by.
+	Source gong.Source
+}
+
+// Compare returns the changes between two snapshots in a stable order, removed items last.
+func Compare(previous, current Snapshot, sources Sources) []Change {
+	var changes []Change
+
+	for _, key := range SortedKeys(current) {
+		item := current[key]
+		old, existed := previous[key]
+
+		if !existed {
+			changes = append(changes, Change{Event: EventAdded, Item: item, Source: item.Source})
+		} else if fields := ChangedFields(old.Definition, item.Definition); len(fields) > 0 {
+			changes = append(changes, Change{Event: EventModified, Item: item, Old: old, ChangedFields: fields, Source: item.Source})
+		}
+	}
+
+	for _, key := range SortedKeys(previous) {
+		if _, ok := current[key]; !ok {
+			old := previous[key]
+			changes = append(changes, Change{Event: EventRemoved, Item: old, Source: sources.lookup(old)})
+		}
+	}
+
+	return changes
+}
+
+// Diff returns the added, removed and modified events between two snapshots,
+// a nil previous snapshot marks all events as initial.
+func Diff(previous, current Snapshot, sources Sources, now time.Time) []map[string]string {
+	initial := previous == nil
+	timeGenerated := now.UTC().Format(iso8601Format)
+
+	var records []map[string]string
+	for _, change := range Compare(previous, current, sources) {
+		record := Record{
+			TimeGenerated:   schema.DateTime(timeGenerated),
+			Event:           change.Event,
+			Kind:            change.Item.Kind,
+			ItemID:          change.Item.ID,
+			ItemName:        change.Item.Name,
+			WorkspaceID:     change.Item.WorkspaceID,
+			InitialSnapshot: initial,
+		}
+
+		switch change.Event {
+		case EventAdded:
+			record.NewValue = change.Item.Definition
+		case EventModified:
+			record.ChangedFields = strings.Join(change.ChangedFields, ",")
+			record.OldValue = change.Old.Definition
+			record.NewValue = change.Item.Definition
+		case EventRemoved:
+			record.OldValue = change.Item.Definition
+		}
+
+		// records only have text, boolean and raw JSON columns, which always encode
+		encoded, _ := schema.Encode(record)
+		change.Source.Annotate(encoded)
+
+		records = append(records, encoded)
+	}
+
+	return records
+}
+
+// ChangedFields returns the dotted paths of all values that differ between two JSON documents.
+func ChangedFields(oldDoc, newDoc json.RawMessage) []string {
+	oldFields, newFields := map[string]string{}, map[string]string{}
+	flatten("", decode(oldDoc), oldFields)
+	flatten("", decode(newDoc), newFields)
+
+	var fields []string
+	for field, value := range newFields {
+		if oldValue, ok := oldFields[field]; !ok || oldValue != value {
+			fields = append(fields, field)
+		}
+	}
+	for field := range oldFields {
+		if _, ok := newFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+func decode(data json.RawMessage) interface{} {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	_ = decoder.Decode(&v)
+	return v
+}
+
+func flatten(prefix string, v interface{}, out map[string]string) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flatten(path, child, out)
+		}
+	default:
+		encoded, _ := json.Marshal(value)
+		out[prefix] = string(encoded)
+	}
+}
+
+// SortedKeys returns the keys of a map in a stable order so change events are emitted deterministically.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
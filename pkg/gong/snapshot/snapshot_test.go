@@ -0,0 +1,150 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"gong2sentinel/pkg/gong"
+	"strings"
+	"testing"
+	"time"
+)
+
+func item(kind, id, definition string) Item {
+	return Item{Kind: kind, ID: id, Name: "name-" + id, WorkspaceID: "w1", Definition: json.RawMessage(definition),
+		Source: gong.Source{RequestID: "current"}}
+}
+
+func snapshotOf(items ...Item) Snapshot {
+	s := Snapshot{}
+	for _, i := range items {
+		s[Key(i.Kind, i.ID)] = i
+	}
+
+	return s
+}
+
+func TestCompare(t *testing.T) {
+	sources := Sources{
+		Scope("tracker", "w1"): {RequestID: "trackers-w1"},
+		Scope("scorecard", ""): {RequestID: "scorecards"},
+	}
+
+	tests := []struct {
+		name     string
+		previous Snapshot
+		current  Snapshot
+		want     []string
+	}{
+		{
+			name:    "nil previous snapshot adds everything",
+			current: snapshotOf(item("tracker", "b", `{"a":1}`), item("tracker", "a", `{"a":1}`)),
+			want:    []string{"added tracker/a  current", "added tracker/b  current"},
+		},
+		{
+			name:     "unchanged",
+			previous: snapshotOf(item("tracker", "a", `{"a":1,"b":[1,2]}`)),
+			current:  snapshotOf(item("tracker", "a", `{"a":1,"b":[1,2]}`)),
+		},
+		{
+			name:     "modified nested and list fields",
+			previous: snapshotOf(item("tracker", "a", `{"a":{"x":1,"y":2},"b":[1,2],"c":true}`)),
+			current:  snapshotOf(item("tracker", "a", `{"a":{"x":1,"y":3},"b":[2,1],"d":null}`)),
+			want:     []string{"modified tracker/a a.y,b,c,d current"},
+		},
+		{
+			name:     "removed items come last with the source of their listing",
+			previous: snapshotOf(item("tracker", "a", `{}`), item("scorecard", "s", `{}`)),
+			current:  snapshotOf(item("tracker", "z", `{}`)),
+			want:     []string{"added tracker/z  current", "removed scorecard/s  scorecards", "removed tracker/a  trackers-w1"},
+		},
+		{
+			name:     "same ID of different kinds",
+			previous: snapshotOf(item("tracker", "1", `{}`)),
+			current:  snapshotOf(item("tracker", "1", `{}`), item("scorecard", "1", `{}`)),
+			want:     []string{"added scorecard/1  current"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, change := range Compare(tt.previous, tt.current, sources) {
+				got = append(got, strings.Join([]string{change.Event, Key(change.Item.Kind, change.Item.ID),
+					strings.Join(change.ChangedFields, ","), change.Source.RequestID}, " "))
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got changes\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		previous Snapshot
+		current  Snapshot
+		want     []map[string]string
+	}{
+		{
+			name:    "initial snapshot",
+			current: snapshotOf(item("tracker", "a", `{"a":1}`)),
+			want: []map[string]string{{
+				"event": EventAdded, "kind": "tracker", "itemId": "a", "itemName": "name-a", "workspaceId": "w1",
+				"initialSnapshot": "true", "newValue": `{"a":1}`, "TimeGenerated": "2024-05-01T00:00:00Z", "requestId": "current",
+			}},
+		},
+		{
+			name:     "modification keeps both values",
+			previous: snapshotOf(item("tracker", "a", `{"a":1}`)),
+			current:  snapshotOf(item("tracker", "a", `{"a":2}`)),
+			want: []map[string]string{{
+				"event": EventModified, "initialSnapshot": "false", "changedFields": "a", "oldValue": `{"a":1}`, "newValue": `{"a":2}`,
+			}},
+		},
+		{
+			name:     "removal only has the old value",
+			previous: snapshotOf(item("tracker", "a", `{"a":1}`)),
+			current:  Snapshot{},
+			want:     []map[string]string{{"event": EventRemoved, "oldValue": `{"a":1}`, "newValue": ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := Diff(tt.previous, tt.current, Sources{}, now)
+			if len(records) != len(tt.want) {
+				t.Fatalf("got %d records, want %d", len(records), len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				for column, value := range want {
+					if got := records[i][column]; got != value {
+						t.Errorf("record %d: column %s = %q, want %q", i, column, got, value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	a, err := Normalize(json.RawMessage(`{"b": 1, "a": {"d": 10000000000000001, "c": [2, 1]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Normalize(json.RawMessage(`{"a":{"c":[2,1],"d":10000000000000001},"b":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(a) != string(b) || string(a) != `{"a":{"c":[2,1],"d":10000000000000001},"b":1}` {
+		t.Errorf("got %s and %s, want equal documents with sorted keys and exact numbers", a, b)
+	}
+
+	if _, err := Normalize(json.RawMessage(`{`)); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
@@ -0,0 +1,153 @@
+package stats
+
+import (
+	"fmt"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/auditing"
+	"gong2sentinel/pkg/schema"
+	"time"
+)
+
+const (
+	iso8601Format = "2006-01-02T15:04:05Z"
+	dateFormat    = "2006-01-02"
+
+	aggregateByPeriodPath = "/v2/stats/activity/aggregate-by-period"
+	aggregationPeriodDay  = "DAY"
+)
+
+// PeriodActivity holds the activity counters of a user for a single period.
+type PeriodActivity struct {
+	FromDate               string `json:"fromDate"`
+	ToDate                 string `json:"toDate"`
+	CallsAsHost            int    `json:"callsAsHost"`
+	CallsAttended          int    `json:"callsAttended"`
+	OwnCallsListenedTo     int    `json:"ownCallsListenedTo"`
+	OthersCallsListenedTo  int    `json:"othersCallsListenedTo"`
+	CallsSharedInternally  int    `json:"callsSharedInternally"`
+	CallsSharedExternally  int    `json:"callsSharedExternally"`
+	CallsCommentsGiven     int    `json:"callsCommentsGiven"`
+	CallsCommentsReceived  int    `json:"callsCommentsReceived"`
+	CallsScorecardsFilled  int    `json:"callsScorecardsFilled"`
+	CallsGaveFeedback      int    `json:"callsGaveFeedback"`
+	CallsRequestedFeedback int    `json:"callsRequestedFeedback"`
+	CallsReceivedFeedback  int    `json:"callsReceivedFeedback"`
+}
+
+// Record is a GongUserActivity record with the counters of one user for one day.
+type Record struct {
+	TimeGenerated    schema.DateTime `json:"TimeGenerated"`
+	UserID           string          `json:"userId"`
+	UserEmailAddress string          `json:"userEmailAddress"`
+	PeriodActivity
+}
+
+// UserActivity is the activity of a single user split per period.
+type UserActivity struct {
+	UserID           string           `json:"userId"`
+	UserEmailAddress string           `json:"userEmailAddress"`
+	ActivityByPeriod []PeriodActivity `json:"activityByPeriod"`
+}
+
+type aggregateByPeriodRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+	Filter struct {
+		FromDate string `json:"fromDate"`
+		ToDate   string `json:"toDate"`
+	} `json:"filter"`
+	AggregationPeriod string `json:"aggregationPeriod"`
+}
+
+// dateRange returns the days to collect, toDate is exclusive. Without a checkpoint these are the days covered by
+// the audit lookup window, otherwise the days after the last completed day. Gong aggregates whole days, so the
+// range ends with yesterday; a window shorter than a day still covers yesterday. The range is empty when
+// yesterday has already been collected.
+func dateRange(now time.Time, lookupHours int64, lastDay string) (string, string, error) {
+	from, to := auditing.LookupWindow(now, lookupHours)
+
+	fromDate := from.Format(dateFormat)
+	toDate := to.Format(dateFormat)
+	if fromDate == toDate {
+		fromDate = to.AddDate(0, 0, -1).Format(dateFormat)
+	}
+
+	if lastDay != "" {
+		day, err := time.Parse(dateFormat, lastDay)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid user activity checkpoint %q: %v", lastDay, err)
+		}
+		fromDate = day.AddDate(0, 0, 1).Format(dateFormat)
+	}
+
+	return fromDate, toDate, nil
+}
+
+// GetDailyActivity returns one record per user and day with the number of calls listened to, shared and commented on,
+// for the completed days after lastDay, or the days of the lookup window when lastDay is empty. It also returns the
+// last completed day to pass on the next run, which should only be persisted once the records have been shipped.
+func GetDailyActivity(client *gong.Client, lookupHours int64, lastDay string) ([]map[string]string, string, error) {
+	request := aggregateByPeriodRequest{AggregationPeriod: aggregationPeriodDay}
+
+	fromDate, toDate, err := dateRange(time.Now(), lookupHours, lastDay)
+	if err != nil {
+		return nil, "", err
+	}
+	if fromDate >= toDate {
+		return []map[string]string{}, lastDay, nil
+	}
+	request.Filter.FromDate, request.Filter.ToDate = fromDate, toDate
+
+	var records []map[string]string
+
+	for page := 0; ; page++ {
+		var response struct {
+			RequestID string `json:"requestId"`
+			Records   struct {
+				Cursor string `json:"cursor"`
+			} `json:"records"`
+			UsersActivity []UserActivity `json:"usersActivity"`
+		}
+		if err := client.Post(aggregateByPeriodPath, request, &response); err != nil {
+			return nil, "", fmt.Errorf("failed to fetch aggregate user activity: %v", err)
+		}
+
+		source := gong.Source{RequestID: response.RequestID, Endpoint: client.URL(aggregateByPeriodPath, nil), PageIndex: page}
+
+		for _, user := range response.UsersActivity {
+			for _, period := range user.ActivityByPeriod {
+				record, err := periodRecord(user, period)
+				if err != nil {
+					return nil, "", err
+				}
+				source.Annotate(record)
+
+				records = append(records, record)
+			}
+		}
+
+		if response.Records.Cursor == "" {
+			break
+		}
+		request.Cursor = response.Records.Cursor
+	}
+
+	toDay, _ := time.Parse(dateFormat, toDate)
+
+	return records, toDay.AddDate(0, 0, -1).Format(dateFormat), nil
+}
+
+// periodRecord converts the activity of a user in a period into a record generated at the start of the period,
+// so the counters of a day land on that day in Sentinel whenever they are collected.
+func periodRecord(user UserActivity, period PeriodActivity) (map[string]string, error) {
+	day, err := time.Parse(dateFormat, period.FromDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid activity period start %q: %v", period.FromDate, err)
+	}
+
+	return schema.Encode(Record{
+		TimeGenerated:    schema.DateTime(day.Format(iso8601Format)),
+		UserID:           user.UserID,
+		UserEmailAddress: user.UserEmailAddress,
+		PeriodActivity:   period,
+	})
+}
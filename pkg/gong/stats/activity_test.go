@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateRange(t *testing.T) {
+	now := time.Date(2024, 5, 10, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		lookupHours int64
+		lastDay     string
+		wantFrom    string
+		wantTo      string
+	}{
+		{name: "lookup window", lookupHours: 72, wantFrom: "2024-05-07", wantTo: "2024-05-10"},
+		{name: "short window covers yesterday", lookupHours: 1, wantFrom: "2024-05-09", wantTo: "2024-05-10"},
+		{name: "resumes after the checkpoint", lookupHours: 24, lastDay: "2024-05-05", wantFrom: "2024-05-06", wantTo: "2024-05-10"},
+		{name: "empty once yesterday is collected", lookupHours: 24, lastDay: "2024-05-09", wantFrom: "2024-05-10", wantTo: "2024-05-10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := dateRange(now, tt.lookupHours, tt.lastDay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if from != tt.wantFrom || to != tt.wantTo {
+				t.Errorf("got %s to %s, want %s to %s", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+
+	if _, _, err := dateRange(now, 24, "yesterday"); err == nil {
+		t.Error("expected an error for an invalid checkpoint")
+	}
+}
+
+func TestPeriodRecord(t *testing.T) {
+	record, err := periodRecord(UserActivity{UserID: "u1"}, PeriodActivity{FromDate: "2024-05-09", ToDate: "2024-05-10", CallsAsHost: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record["TimeGenerated"] != "2024-05-09T00:00:00Z" {
+		t.Errorf("got TimeGenerated %s, want the start of the period", record["TimeGenerated"])
+	}
+	if record["callsAsHost"] != "3" || record["userId"] != "u1" {
+		t.Errorf("got record %v", record)
+	}
+}
@@ -0,0 +1,111 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/schema"
+	"sort"
+	"time"
+)
+
+// SettingsHistoryEntry is a value a user setting held from StartTime onwards.
+type SettingsHistoryEntry struct {
+	Setting   string          `json:"setting"`
+	Value     json.RawMessage `json:"value"`
+	StartTime time.Time       `json:"startTime"`
+}
+
+// SettingsChangeRecord is a GongUserSettings record, the values are the JSON values of the setting.
+type SettingsChangeRecord struct {
+	TimeGenerated schema.DateTime `json:"TimeGenerated"`
+	UserID        string          `json:"userId"`
+	EmailAddress  string          `json:"emailAddress"`
+	Setting       string          `json:"setting"`
+	OldValue      json.RawMessage `json:"oldValue"`
+	NewValue      json.RawMessage `json:"newValue"`
+	ChangedAt     schema.DateTime `json:"changedAt"`
+}
+
+func settingsHistoryPath(userID string) string {
+	return fmt.Sprintf("/v2/users/%s/settings-history", userID)
+}
+
+// GetSettingsHistory returns the settings history of a single user.
+func GetSettingsHistory(client *gong.Client, userID string) ([]SettingsHistoryEntry, gong.Source, error) {
+	path := settingsHistoryPath(userID)
+
+	var response struct {
+		RequestID           string                 `json:"requestId"`
+		UserSettingsHistory []SettingsHistoryEntry `json:"userSettingsHistory"`
+	}
+	if err := client.Get(path, nil, &response); err != nil {
+		return nil, gong.Source{}, fmt.Errorf("failed to fetch settings history for user %s: %v", userID, err)
+	}
+
+	return response.UserSettingsHistory, gong.Source{RequestID: response.RequestID, Endpoint: client.URL(path, nil)}, nil
+}
+
+// GetSettingsChanges returns one record per setting change of the given users that happened after the user's
+// checkpoint, users is the list already fetched with GetUsers. The returned checkpoints should only be persisted
+// once the records have been shipped.
+func GetSettingsChanges(client *gong.Client, users []User, checkpoints map[string]time.Time) ([]map[string]string, map[string]time.Time, error) {
+	now := time.Now().UTC().Format(iso8601Format)
+
+	next := make(map[string]time.Time, len(users))
+	for userID, checkpoint := range checkpoints {
+		next[userID] = checkpoint
+	}
+
+	var records []map[string]string
+
+	for _, user := range users {
+		history, source, err := GetSettingsHistory(client, user.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		checkpoint := checkpoints[user.ID]
+
+		sort.SliceStable(history, func(i, j int) bool {
+			return history[i].StartTime.Before(history[j].StartTime)
+		})
+
+		previous := map[string]json.RawMessage{}
+		for _, entry := range history {
+			oldValue, seen := previous[entry.Setting]
+			previous[entry.Setting] = entry.Value
+
+			if !entry.StartTime.After(checkpoint) {
+				continue
+			}
+
+			if entry.StartTime.After(next[user.ID]) {
+				next[user.ID] = entry.StartTime
+			}
+
+			// the first value of a setting is its initial state rather than a change
+			if !seen || string(oldValue) == string(entry.Value) {
+				continue
+			}
+
+			record, err := schema.Encode(SettingsChangeRecord{
+				TimeGenerated: schema.DateTime(now),
+				UserID:        user.ID,
+				EmailAddress:  user.EmailAddress,
+				Setting:       entry.Setting,
+				OldValue:      oldValue,
+				NewValue:      entry.Value,
+				ChangedAt:     schema.DateTime(entry.StartTime.UTC().Format(iso8601Format)),
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			source.Annotate(record)
+
+			records = append(records, record)
+		}
+	}
+
+	return records, next, nil
+}
@@ -0,0 +1,104 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetSettingsChanges(t *testing.T) {
+	t1 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	t2, t3 := t1.Add(time.Hour), t1.Add(2*time.Hour)
+
+	entry := func(setting, value string, start time.Time) SettingsHistoryEntry {
+		return SettingsHistoryEntry{Setting: setting, Value: json.RawMessage(value), StartTime: start}
+	}
+
+	tests := []struct {
+		name           string
+		history        []SettingsHistoryEntry
+		checkpoint     time.Time
+		wantChanges    []string
+		wantCheckpoint time.Time
+	}{
+		{
+			name:           "the first value is not a change",
+			history:        []SettingsHistoryEntry{entry("recording", `true`, t1)},
+			wantCheckpoint: t1,
+		},
+		{
+			name:           "changed value",
+			history:        []SettingsHistoryEntry{entry("recording", `true`, t1), entry("recording", `false`, t2)},
+			wantChanges:    []string{"recording true>false"},
+			wantCheckpoint: t2,
+		},
+		{
+			name:           "unchanged value",
+			history:        []SettingsHistoryEntry{entry("recording", `true`, t1), entry("recording", `true`, t2)},
+			wantCheckpoint: t2,
+		},
+		{
+			name: "history is sorted and settings are tracked separately",
+			history: []SettingsHistoryEntry{
+				entry("recording", `false`, t3), entry("language", `"en"`, t2), entry("recording", `true`, t1),
+			},
+			wantChanges:    []string{"recording true>false"},
+			wantCheckpoint: t3,
+		},
+		{
+			name:           "values up to the checkpoint are only the old value",
+			history:        []SettingsHistoryEntry{entry("recording", `true`, t1), entry("recording", `false`, t2), entry("recording", `true`, t3)},
+			checkpoint:     t2,
+			wantChanges:    []string{"recording false>true"},
+			wantCheckpoint: t3,
+		},
+		{
+			name:           "nothing after the checkpoint",
+			history:        []SettingsHistoryEntry{entry("recording", `true`, t1), entry("recording", `false`, t2)},
+			checkpoint:     t2,
+			wantCheckpoint: t2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != settingsHistoryPath("u1") {
+					http.NotFound(w, r)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"requestId": "r1", "userSettingsHistory": tt.history})
+			}))
+
+			checkpoints := map[string]time.Time{"other": t1}
+			if !tt.checkpoint.IsZero() {
+				checkpoints["u1"] = tt.checkpoint
+			}
+
+			records, next, err := GetSettingsChanges(client, []User{{ID: "u1", EmailAddress: "jane@example.com"}}, checkpoints)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, record := range records {
+				got = append(got, record["setting"]+" "+record["oldValue"]+">"+record["newValue"])
+				if record["requestId"] != "r1" || record["emailAddress"] != "jane@example.com" {
+					t.Errorf("record %v is not annotated with the user and source", record)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantChanges, ",") {
+				t.Errorf("got changes %v, want %v", got, tt.wantChanges)
+			}
+
+			if !next["u1"].Equal(tt.wantCheckpoint) {
+				t.Errorf("got checkpoint %s, want %s", next["u1"], tt.wantCheckpoint)
+			}
+			if !next["other"].Equal(t1) {
+				t.Error("the checkpoint of another user was dropped")
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/schema"
+	"time"
+)
+
+const (
+	iso8601Format      = "2006-01-02T15:04:05Z"
+	extensiveUsersPath = "/v2/users/extensive"
+)
+
+// User is a Gong user as returned by the extensive users endpoint.
+type User struct {
+	ID                    string          `json:"id"`
+	EmailAddress          string          `json:"emailAddress"`
+	Created               string          `json:"created"`
+	Active                bool            `json:"active"`
+	EmailAliases          []string        `json:"emailAliases"`
+	TrustedEmailAddress   string          `json:"trustedEmailAddress"`
+	FirstName             string          `json:"firstName"`
+	LastName              string          `json:"lastName"`
+	Title                 string          `json:"title"`
+	PhoneNumber           string          `json:"phoneNumber"`
+	Extension             string          `json:"extension"`
+	PersonalMeetingUrls   []string        `json:"personalMeetingUrls"`
+	Settings              json.RawMessage `json:"settings"`
+	ManagerID             string          `json:"managerId"`
+	MeetingConsentPageURL string          `json:"meetingConsentPageUrl"`
+	SpokenLanguages       json.RawMessage `json:"spokenLanguages"`
+
+	// Source is the API call the user was returned by.
+	Source gong.Source `json:"-"`
+}
+
+type extensiveUsersRequest struct {
+	Cursor string   `json:"cursor,omitempty"`
+	Filter struct{} `json:"filter"`
+}
+
+// GetUsers pages through all Gong users including their extensive details.
+func GetUsers(client *gong.Client) ([]User, error) {
+	var users []User
+
+	request := extensiveUsersRequest{}
+
+	for page := 0; ; page++ {
+		var response struct {
+			RequestID string `json:"requestId"`
+			Records   struct {
+				Cursor string `json:"cursor"`
+			} `json:"records"`
+			Users []User `json:"users"`
+		}
+		if err := client.Post(extensiveUsersPath, request, &response); err != nil {
+			return nil, fmt.Errorf("failed to fetch users: %v", err)
+		}
+
+		source := gong.Source{RequestID: response.RequestID, Endpoint: client.URL(extensiveUsersPath, nil), PageIndex: page}
+		for _, user := range response.Users {
+			user.Source = source
+			users = append(users, user)
+		}
+
+		if response.Records.Cursor == "" {
+			break
+		}
+		request.Cursor = response.Records.Cursor
+	}
+
+	return users, nil
+}
+
+// Record is a GongUsers record.
+type Record struct {
+	TimeGenerated       schema.DateTime `json:"TimeGenerated"`
+	UserID              string          `json:"userId"`
+	EmailAddress        string          `json:"emailAddress"`
+	EmailAliases        []string        `json:"emailAliases"`
+	TrustedEmailAddress string          `json:"trustedEmailAddress"`
+	FirstName           string          `json:"firstName"`
+	LastName            string          `json:"lastName"`
+	Title               string          `json:"title"`
+	PhoneNumber         string          `json:"phoneNumber"`
+	ManagerID           string          `json:"managerId"`
+	Active              bool            `json:"active"`
+	Created             schema.DateTime `json:"created"`
+	Settings            json.RawMessage `json:"settings"`
+	SpokenLanguages     json.RawMessage `json:"spokenLanguages"`
+}
+
+// GetUserSnapshot returns one record per Gong user for the GongUsers stream, users is the list fetched with GetUsers.
+func GetUserSnapshot(users []User) ([]map[string]string, error) {
+	now := time.Now().UTC().Format(iso8601Format)
+	records := make([]map[string]string, len(users))
+
+	for i, user := range users {
+		record, err := schema.Encode(Record{
+			TimeGenerated:       schema.DateTime(now),
+			UserID:              user.ID,
+			EmailAddress:        user.EmailAddress,
+			EmailAliases:        user.EmailAliases,
+			TrustedEmailAddress: user.TrustedEmailAddress,
+			FirstName:           user.FirstName,
+			LastName:            user.LastName,
+			Title:               user.Title,
+			PhoneNumber:         user.PhoneNumber,
+			ManagerID:           user.ManagerID,
+			Active:              user.Active,
+			Created:             schema.DateTime(user.Created),
+			Settings:            user.Settings,
+			SpokenLanguages:     user.SpokenLanguages,
+		})
+		if err != nil {
+			return nil, err
+		}
+		user.Source.Annotate(record)
+
+		records[i] = record
+	}
+
+	return records, nil
+}
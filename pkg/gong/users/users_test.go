@@ -0,0 +1,105 @@
+package users
+
+import (
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient returns a client for a handler without the Gong rate limit.
+func newTestClient(t *testing.T, handler http.Handler) *gong.Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := gong.New(server.URL, "key", "secret")
+	client.SetRateLimit(-1)
+
+	return client
+}
+
+func TestGetUsersPages(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	client := newTestClient(t, mock.New(logger, mock.Options{AccessKey: "key", AccessSecret: "secret", Seed: 1, Users: 7, PageSize: 3}))
+
+	users, err := GetUsers(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 7 {
+		t.Fatalf("got %d users, want 7", len(users))
+	}
+
+	pages := map[int]int{}
+	for _, user := range users {
+		pages[user.Source.PageIndex]++
+	}
+	if pages[0] != 3 || pages[1] != 3 || pages[2] != 1 {
+		t.Errorf("got users per page %v, want 3, 3 and 1", pages)
+	}
+}
+
+func TestGetUserSnapshot(t *testing.T) {
+	tests := []struct {
+		name string
+		user User
+		want map[string]string
+	}{
+		{
+			name: "full user",
+			user: User{
+				ID:           "u1",
+				EmailAddress: "jane@example.com",
+				EmailAliases: []string{"j@example.com"},
+				Active:       true,
+				Created:      "2024-01-02T03:04:05Z",
+				Settings:     json.RawMessage(`{"emailsImported":true}`),
+				Source:       gong.Source{RequestID: "r1", PageIndex: 2},
+			},
+			want: map[string]string{
+				"userId":       "u1",
+				"emailAddress": "jane@example.com",
+				"emailAliases": `["j@example.com"]`,
+				"active":       "true",
+				"created":      "2024-01-02T03:04:05Z",
+				"settings":     `{"emailsImported":true}`,
+				"requestId":    "r1",
+				"pageIndex":    "2",
+			},
+		},
+		{
+			name: "inactive user without aliases",
+			user: User{ID: "u2"},
+			want: map[string]string{"userId": "u2", "active": "false", "requestId": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := GetUserSnapshot([]User{tt.user})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 1 {
+				t.Fatalf("got %d records, want 1", len(records))
+			}
+
+			for column, want := range tt.want {
+				if got := records[0][column]; got != want {
+					t.Errorf("column %s = %q, want %q", column, got, want)
+				}
+			}
+			if records[0]["TimeGenerated"] == "" {
+				t.Error("no TimeGenerated")
+			}
+		})
+	}
+}
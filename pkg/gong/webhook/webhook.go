@@ -0,0 +1,193 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/schema"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	iso8601Format = "2006-01-02T15:04:05Z"
+
+	maxBodyBytes = 10 << 20
+)
+
+// ForwardFunc ships the records converted from a webhook call.
+type ForwardFunc func(ctx context.Context, records []map[string]string) error
+
+// Handler receives Gong automation rule webhooks signed with a JWT in the Authorization header.
+type Handler struct {
+	logger *logrus.Entry
+
+	publicKey  *rsa.PublicKey
+	webhookURL string
+	forward    ForwardFunc
+}
+
+// gongClaims are the claims Gong puts in the signed webhook JWT.
+type gongClaims struct {
+	WebhookURL string `json:"webhook_url"`
+	BodySHA256 string `json:"body_sha256"`
+	jwt.RegisteredClaims
+}
+
+// ParsePublicKey parses the PEM encoded public key shown in the Gong automation rule settings.
+func ParsePublicKey(pemData []byte) (*rsa.PublicKey, error) {
+	key, err := jwt.ParseRSAPublicKeyFromPEM(pemData)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse gong webhook public key: %v", err)
+	}
+
+	return key, nil
+}
+
+// New creates a handler verifying webhooks against publicKey. webhookURL is the public URL configured
+// in the Gong automation rule and must match the URL claim in the JWT.
+func New(logger *logrus.Logger, publicKey *rsa.PublicKey, webhookURL string, forward ForwardFunc) *Handler {
+	return &Handler{
+		logger:     logger.WithField("module", "gong_webhook"),
+		publicKey:  publicKey,
+		webhookURL: webhookURL,
+		forward:    forward,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.WithField("remote", r.RemoteAddr)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		logger.WithError(err).Warn("could not read webhook body")
+		http.Error(w, "could not read body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verify(r.Header.Get("Authorization"), body); err != nil {
+		logger.WithError(err).Warn("rejecting webhook with invalid signature")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	records, err := ToRecords(body, time.Now())
+	if err != nil {
+		logger.WithError(err).Warn("could not convert webhook payload")
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	// a failed forward returns a 5xx so Gong retries the delivery
+	if err := h.forward(r.Context(), records); err != nil {
+		logger.WithError(err).Error("could not forward webhook records")
+		http.Error(w, "could not forward records", http.StatusBadGateway)
+		return
+	}
+
+	logger.WithField("total", len(records)).Info("forwarded webhook records")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) verify(authorization string, body []byte) error {
+	tokenString := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	if tokenString == "" {
+		return fmt.Errorf("missing authorization header")
+	}
+
+	claims := &gongClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return h.publicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return fmt.Errorf("invalid token: %v", err)
+	}
+
+	if claims.WebhookURL != h.webhookURL {
+		return fmt.Errorf("token was issued for another webhook url: %s", claims.WebhookURL)
+	}
+
+	digest := sha256.Sum256(body)
+	if subtle.ConstantTimeCompare([]byte(strings.ToLower(claims.BodySHA256)), []byte(hex.EncodeToString(digest[:]))) != 1 {
+		return fmt.Errorf("body digest does not match token")
+	}
+
+	return nil
+}
+
+// Record is a GongWebhook record, the full payload is kept as is.
+type Record struct {
+	TimeGenerated schema.DateTime   `json:"TimeGenerated"`
+	CallID        string            `json:"callId"`
+	CallURL       string            `json:"callUrl"`
+	Title         string            `json:"title"`
+	Started       schema.DateTime   `json:"started"`
+	PrimaryUserID string            `json:"primaryUserId"`
+	WorkspaceID   string            `json:"workspaceId"`
+	Scope         string            `json:"scope"`
+	IsPrivate     bool              `json:"isPrivate"`
+	IsTest        bool              `json:"isTest"`
+	Parties       []json.RawMessage `json:"parties"`
+	Payload       json.RawMessage   `json:"payload"`
+}
+
+// ToRecords converts a Gong automation rule payload into the record format shipped to Sentinel.
+func ToRecords(body []byte, now time.Time) ([]map[string]string, error) {
+	var payload struct {
+		IsTest   bool `json:"isTest"`
+		CallData struct {
+			MetaData struct {
+				ID            string `json:"id"`
+				URL           string `json:"url"`
+				Title         string `json:"title"`
+				Started       string `json:"started"`
+				PrimaryUserID string `json:"primaryUserId"`
+				WorkspaceID   string `json:"workspaceId"`
+				Scope         string `json:"scope"`
+				IsPrivate     bool   `json:"isPrivate"`
+			} `json:"metaData"`
+			Parties []json.RawMessage `json:"parties"`
+		} `json:"callData"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook payload: %v", err)
+	}
+
+	metaData := payload.CallData.MetaData
+	if metaData.ID == "" {
+		return nil, fmt.Errorf("webhook payload does not reference a call")
+	}
+
+	record, err := schema.Encode(Record{
+		TimeGenerated: schema.DateTime(now.UTC().Format(iso8601Format)),
+		CallID:        metaData.ID,
+		CallURL:       metaData.URL,
+		Title:         metaData.Title,
+		Started:       schema.DateTime(metaData.Started),
+		PrimaryUserID: metaData.PrimaryUserID,
+		WorkspaceID:   metaData.WorkspaceID,
+		Scope:         metaData.Scope,
+		IsPrivate:     metaData.IsPrivate,
+		IsTest:        payload.IsTest,
+		Parties:       payload.CallData.Parties,
+		Payload:       body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []map[string]string{record}, nil
+}
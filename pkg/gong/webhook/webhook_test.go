@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWebhookURL = "https://gong2sentinel.example.com/webhook"
+
+func discardLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return logger
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+
+	return key
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, method jwt.SigningMethod, claims gongClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("could not sign token: %v", err)
+	}
+
+	return token
+}
+
+func bodyDigest(body string) string {
+	digest := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(digest[:])
+}
+
+func TestServeHTTP(t *testing.T) {
+	key := generateKey(t)
+	otherKey := generateKey(t)
+
+	body := `{"isTest":true,"callData":{"metaData":{"id":"123","url":"https://app.gong.io/call?id=123","started":"2024-01-01T10:00:00Z"},"parties":[{"id":"1"}]}}`
+	valid := gongClaims{
+		WebhookURL: testWebhookURL,
+		BodySHA256: bodyDigest(body),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+
+	withClaims := func(change func(*gongClaims)) gongClaims {
+		claims := valid
+		change(&claims)
+		return claims
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		body          string
+		wantStatus    int
+	}{
+		{
+			name:          "valid",
+			authorization: "Bearer " + signToken(t, key, jwt.SigningMethodRS256, valid),
+			body:          body,
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:       "missing authorization",
+			body:       body,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "wrong key",
+			authorization: "Bearer " + signToken(t, otherKey, jwt.SigningMethodRS256, valid),
+			body:          body,
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "other algorithm",
+			authorization: "Bearer " + signToken(t, key, jwt.SigningMethodRS512, valid),
+			body:          body,
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name: "expired",
+			authorization: "Bearer " + signToken(t, key, jwt.SigningMethodRS256, withClaims(func(c *gongClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			})),
+			body:       body,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "no expiry",
+			authorization: "Bearer " + signToken(t, key, jwt.SigningMethodRS256, withClaims(func(c *gongClaims) {
+				c.ExpiresAt = nil
+			})),
+			body:       body,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "webhook url mismatch",
+			authorization: "Bearer " + signToken(t, key, jwt.SigningMethodRS256, withClaims(func(c *gongClaims) {
+				c.WebhookURL = "https://attacker.example.com/webhook"
+			})),
+			body:       body,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "body sha256 mismatch",
+			authorization: "Bearer " + signToken(t, key, jwt.SigningMethodRS256, valid),
+			body:          strings.Replace(body, "123", "456", 1),
+			wantStatus:    http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var forwarded []map[string]string
+			handler := New(discardLogger(), &key.PublicKey, testWebhookURL, func(ctx context.Context, records []map[string]string) error {
+				forwarded = records
+				return nil
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.body))
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				if forwarded != nil {
+					t.Fatalf("rejected webhook was forwarded: %v", forwarded)
+				}
+				return
+			}
+
+			// test deliveries from the Gong automation rule settings are forwarded and marked as such
+			if len(forwarded) != 1 {
+				t.Fatalf("forwarded %d records, want 1", len(forwarded))
+			}
+			if forwarded[0]["isTest"] != "true" || forwarded[0]["callId"] != "123" {
+				t.Fatalf("unexpected record: %v", forwarded[0])
+			}
+		})
+	}
+}
+
+func TestServeHTTPForwardFailure(t *testing.T) {
+	key := generateKey(t)
+	body := `{"callData":{"metaData":{"id":"123"}}}`
+
+	handler := New(discardLogger(), &key.PublicKey, testWebhookURL, func(ctx context.Context, records []map[string]string) error {
+		return io.ErrUnexpectedEOF
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	request.Header.Set("Authorization", "Bearer "+signToken(t, key, jwt.SigningMethodRS256, gongClaims{
+		WebhookURL: testWebhookURL,
+		BodySHA256: bodyDigest(body),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}))
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, request)
+
+	// a 5xx makes Gong retry the delivery
+	if recorder.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,94 @@
+package workspaces
+
+import (
+	"fmt"
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/schema"
+	"time"
+)
+
+const (
+	iso8601Format  = "2006-01-02T15:04:05Z"
+	workspacesPath = "/v2/workspaces"
+)
+
+// Workspace is a Gong workspace.
+type Workspace struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// Source is the API call the workspace was returned by.
+	Source gong.Source `json:"-"`
+}
+
+// GetWorkspaces returns all workspaces of the company.
+func GetWorkspaces(client *gong.Client) ([]Workspace, error) {
+	var response struct {
+		RequestID  string      `json:"requestId"`
+		Workspaces []Workspace `json:"workspaces"`
+	}
+	if err := client.Get(workspacesPath, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to fetch workspaces: %v", err)
+	}
+
+	source := gong.Source{RequestID: response.RequestID, Endpoint: client.URL(workspacesPath, nil)}
+	for i := range response.Workspaces {
+		response.Workspaces[i].Source = source
+	}
+
+	return response.Workspaces, nil
+}
+
+// Record is a GongWorkspaces record.
+type Record struct {
+	TimeGenerated schema.DateTime `json:"TimeGenerated"`
+	WorkspaceID   string          `json:"workspaceId"`
+	WorkspaceName string          `json:"workspaceName"`
+	Description   string          `json:"description"`
+}
+
+// Enrichment holds the columns Enrich adds to records of other collectors.
+type Enrichment struct {
+	WorkspaceName string `json:"workspaceName"`
+}
+
+// ToRecords converts workspaces into records for the GongWorkspaces stream.
+func ToRecords(workspaces []Workspace) []map[string]string {
+	now := time.Now().UTC().Format(iso8601Format)
+
+	records := make([]map[string]string, len(workspaces))
+	for i, workspace := range workspaces {
+		// workspaces only have text columns, which always encode
+		records[i], _ = schema.Encode(Record{
+			TimeGenerated: schema.DateTime(now),
+			WorkspaceID:   workspace.ID,
+			WorkspaceName: workspace.Name,
+			Description:   workspace.Description,
+		})
+		workspace.Source.Annotate(records[i])
+	}
+
+	return records
+}
+
+// Lookup maps workspace IDs to their name.
+type Lookup map[string]string
+
+func NewLookup(workspaces []Workspace) Lookup {
+	lookup := make(Lookup, len(workspaces))
+	for _, workspace := range workspaces {
+		lookup[workspace.ID] = workspace.Name
+	}
+
+	return lookup
+}
+
+// Enrich adds a workspaceName column to every record that references a known workspaceId.
+func (l Lookup) Enrich(records []map[string]string) {
+	for _, record := range records {
+		if name, ok := l[record["workspaceId"]]; ok {
+			_ = schema.Merge(record, Enrichment{WorkspaceName: name})
+		}
+	}
+}
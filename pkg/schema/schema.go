@@ -0,0 +1,233 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	TypeString   = "string"
+	TypeBoolean  = "boolean"
+	TypeLong     = "long"
+	TypeReal     = "real"
+	TypeDateTime = "datetime"
+	TypeDynamic  = "dynamic"
+)
+
+// DateTime is a timestamp shipped as text, it is stored in a datetime column.
+type DateTime string
+
+var (
+	dateTimeType = reflect.TypeOf(DateTime(""))
+	rawJSONType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// conversions are the KQL functions turning the shipped text into the column type.
+var conversions = map[string]string{
+	TypeBoolean:  "tobool",
+	TypeLong:     "tolong",
+	TypeReal:     "toreal",
+	TypeDateTime: "todatetime",
+	TypeDynamic:  "todynamic",
+}
+
+// Column is a column of a custom table or of the DCR stream feeding it.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Table describes the custom Log Analytics table the records of a collector are ingested in.
+type Table struct {
+	// Name is the table name without the _CL suffix of custom tables.
+	Name        string
+	Description string
+	Columns     []Column
+}
+
+// NewTable derives the columns of a table from the record structs that make up its records,
+// e.g. the collector's record and the source columns added to it. A column tag overrides the derived type.
+func NewTable(name, description string, records ...interface{}) Table {
+	table := Table{Name: name, Description: description}
+
+	for _, record := range records {
+		for _, field := range fields(reflect.TypeOf(record)) {
+			typ := field.columnType
+			if typ == "" {
+				typ = columnType(field.typ)
+			}
+			table.Columns = append(table.Columns, Column{Name: field.name, Type: typ})
+		}
+	}
+
+	return table
+}
+
+// TableName returns the name of the custom table in Log Analytics.
+func (t Table) TableName() string {
+	return t.Name + "_CL"
+}
+
+// StreamName returns the conventional name of the DCR input stream shipping to the table.
+func (t Table) StreamName() string {
+	return "Custom-" + t.Name
+}
+
+// OutputStream returns the DCR output stream that writes to the table.
+func (t Table) OutputStream() string {
+	return "Custom-" + t.TableName()
+}
+
+// StreamColumns returns the columns of the DCR stream declaration, every value is shipped as text.
+func (t Table) StreamColumns() []Column {
+	columns := make([]Column, len(t.Columns))
+	for i, column := range t.Columns {
+		columns[i] = Column{Name: column.Name, Type: TypeString}
+	}
+
+	return columns
+}
+
+// TransformKQL returns the DCR transformation converting the shipped text to the column types of the table.
+func (t Table) TransformKQL() string {
+	var conversion []string
+	for _, column := range t.Columns {
+		if function, ok := conversions[column.Type]; ok {
+			conversion = append(conversion, fmt.Sprintf("%s = %s(%s)", column.Name, function, column.Name))
+		}
+	}
+
+	if len(conversion) == 0 {
+		return "source"
+	}
+
+	return "source | extend " + strings.Join(conversion, ", ")
+}
+
+// Encode converts a record struct into the record shipped to Sentinel. Strings, numbers and booleans are
+// shipped as text, json.RawMessage as is and other values JSON encoded. Fields tagged omitempty are left out when empty.
+func Encode(record interface{}) (map[string]string, error) {
+	encoded := map[string]string{}
+
+	value := reflect.ValueOf(record)
+	for _, field := range fields(value.Type()) {
+		fieldValue := value.FieldByIndex(field.index)
+		if field.omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+
+		text, err := encodeValue(fieldValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode column %s: %v", field.name, err)
+		}
+		encoded[field.name] = text
+	}
+
+	return encoded, nil
+}
+
+// Merge adds the columns of a record struct to an existing record, such as the source columns of the API call.
+func Merge(record map[string]string, columns interface{}) error {
+	encoded, err := Encode(columns)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range encoded {
+		record[name] = value
+	}
+
+	return nil
+}
+
+func encodeValue(value reflect.Value) (string, error) {
+	if value.Type() == rawJSONType {
+		return string(value.Bytes()), nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return fmt.Sprint(value.Interface()), nil
+	}
+
+	encoded, err := json.Marshal(value.Interface())
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+func columnType(typ reflect.Type) string {
+	switch {
+	case typ == dateTimeType:
+		return TypeDateTime
+	case typ == rawJSONType:
+		return TypeDynamic
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return TypeString
+	case reflect.Bool:
+		return TypeBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return TypeLong
+	case reflect.Float32, reflect.Float64:
+		return TypeReal
+	}
+
+	return TypeDynamic
+}
+
+type field struct {
+	name       string
+	index      []int
+	typ        reflect.Type
+	columnType string
+	omitEmpty  bool
+}
+
+// fields lists the columns of a record struct, named by their json tag. Embedded structs add their columns in place.
+func fields(typ reflect.Type) []field {
+	if typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("schema: record %s is not a struct", typ))
+	}
+
+	var columns []field
+	for i := 0; i < typ.NumField(); i++ {
+		structField := typ.Field(i)
+
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct {
+			for _, embedded := range fields(structField.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				columns = append(columns, embedded)
+			}
+			continue
+		}
+
+		name, options, _ := strings.Cut(structField.Tag.Get("json"), ",")
+		if !structField.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = structField.Name
+		}
+
+		columns = append(columns, field{
+			name:       name,
+			index:      []int{i},
+			typ:        structField.Type,
+			columnType: structField.Tag.Get("column"),
+			omitEmpty:  options == "omitempty",
+		})
+	}
+
+	return columns
+}
@@ -0,0 +1,164 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+type embeddedColumns struct {
+	Inner string `json:"inner"`
+}
+
+type testRecord struct {
+	TimeGenerated DateTime        `json:"TimeGenerated"`
+	Name          string          `json:"name"`
+	Count         int             `json:"count"`
+	Ratio         float64         `json:"ratio"`
+	Active        bool            `json:"active"`
+	Payload       json.RawMessage `json:"payload"`
+	Legacy        json.RawMessage `json:"legacy" column:"string"`
+	Tags          []string        `json:"tags"`
+	Optional      string          `json:"optional,omitempty"`
+	OptionalJSON  json.RawMessage `json:"optionalJson,omitempty"`
+	Untagged      string
+	Ignored       string `json:"-"`
+	unexported    string
+	embeddedColumns
+}
+
+type sourceColumns struct {
+	RequestID string `json:"requestId"`
+}
+
+func TestNewTable(t *testing.T) {
+	table := NewTable("GongTest", "Test records", testRecord{}, sourceColumns{})
+
+	want := []Column{
+		{Name: "TimeGenerated", Type: TypeDateTime},
+		{Name: "name", Type: TypeString},
+		{Name: "count", Type: TypeLong},
+		{Name: "ratio", Type: TypeReal},
+		{Name: "active", Type: TypeBoolean},
+		{Name: "payload", Type: TypeDynamic},
+		{Name: "legacy", Type: TypeString},
+		{Name: "tags", Type: TypeDynamic},
+		{Name: "optional", Type: TypeString},
+		{Name: "optionalJson", Type: TypeDynamic},
+		{Name: "Untagged", Type: TypeString},
+		{Name: "inner", Type: TypeString},
+		{Name: "requestId", Type: TypeString},
+	}
+	if fmt.Sprint(table.Columns) != fmt.Sprint(want) {
+		t.Errorf("got columns\n%v\nwant\n%v", table.Columns, want)
+	}
+
+	if table.TableName() != "GongTest_CL" || table.StreamName() != "Custom-GongTest" || table.OutputStream() != "Custom-GongTest_CL" {
+		t.Errorf("got names %s, %s and %s", table.TableName(), table.StreamName(), table.OutputStream())
+	}
+	for _, column := range table.StreamColumns() {
+		if column.Type != TypeString {
+			t.Errorf("stream column %s is %s, every value is shipped as text", column.Name, column.Type)
+		}
+	}
+}
+
+func TestTransformKQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []interface{}
+		want    string
+	}{
+		{
+			name:    "conversions for every non-text column",
+			records: []interface{}{testRecord{}},
+			want: "source | extend TimeGenerated = todatetime(TimeGenerated), count = tolong(count), ratio = toreal(ratio), " +
+				"active = tobool(active), payload = todynamic(payload), tags = todynamic(tags), optionalJson = todynamic(optionalJson)",
+		},
+		{
+			name:    "text only",
+			records: []interface{}{sourceColumns{}},
+			want:    "source",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewTable("GongTest", "", tt.records...).TransformKQL(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name   string
+		record testRecord
+		want   map[string]string
+	}{
+		{
+			name: "values are shipped as text",
+			record: testRecord{
+				TimeGenerated: "2024-05-01T00:00:00Z",
+				Name:          "n",
+				Count:         3,
+				Ratio:         0.5,
+				Active:        true,
+				Payload:       json.RawMessage(`{"a":1}`),
+				Legacy:        json.RawMessage(`{"b":2}`),
+				Tags:          []string{"x"},
+				Optional:      "o",
+				OptionalJSON:  json.RawMessage(`[1]`),
+				Untagged:      "u",
+				Ignored:       "i",
+				unexported:    "e",
+				embeddedColumns: embeddedColumns{
+					Inner: "in",
+				},
+			},
+			want: map[string]string{
+				"TimeGenerated": "2024-05-01T00:00:00Z", "name": "n", "count": "3", "ratio": "0.5", "active": "true",
+				"payload": `{"a":1}`, "legacy": `{"b":2}`, "tags": `["x"]`, "optional": "o", "optionalJson": "[1]",
+				"Untagged": "u", "inner": "in",
+			},
+		},
+		{
+			name:   "empty omitempty fields are left out",
+			record: testRecord{},
+			want: map[string]string{
+				"TimeGenerated": "", "name": "", "count": "0", "ratio": "0", "active": "false",
+				"payload": "", "legacy": "", "tags": "null", "Untagged": "", "inner": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Encode(tt.record)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d columns, want %d: %v", len(got), len(tt.want), got)
+			}
+			for column, want := range tt.want {
+				if value, ok := got[column]; !ok || value != want {
+					t.Errorf("column %s = %q, want %q", column, value, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	record := map[string]string{"name": "n", "requestId": "old"}
+	if err := Merge(record, sourceColumns{RequestID: "r1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if record["name"] != "n" || record["requestId"] != "r1" {
+		t.Errorf("got %v", record)
+	}
+}
@@ -0,0 +1,179 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	armModule  = "gong2sentinel"
+	armVersion = "v1.0.0"
+
+	armTimeout      = time.Second * 30
+	armPollInterval = time.Second * 5
+	armPollTimeout  = time.Minute * 10
+)
+
+// armResource is the JSON representation of an Azure resource.
+type armResource = map[string]interface{}
+
+// UseARMStandIn sends ARM requests to a local stand-in such as the mock-arm command instead of Azure.
+// The stand-in does not check tokens, so no credential is needed.
+func (s *Sentinel) UseARMStandIn(endpoint string) {
+	s.armEndpoint = endpoint
+}
+
+// armClient returns the ARM client of the configured cloud, it is created once and reused.
+func (s *Sentinel) armClient() (*arm.Client, error) {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	if s.arm != nil {
+		return s.arm, nil
+	}
+
+	var cred azcore.TokenCredential = s.azCreds
+	opts := &arm.ClientOptions{ClientOptions: policy.ClientOptions{Cloud: s.creds.Cloud}}
+
+	if s.armEndpoint != "" {
+		cred = standInCredential{}
+		opts.Cloud.Services = map[cloud.ServiceName]cloud.ServiceConfiguration{
+			cloud.ResourceManager: {Endpoint: s.armEndpoint, Audience: s.armEndpoint},
+		}
+		opts.InsecureAllowCredentialWithHTTP = true
+		opts.DisableRPRegistration = true
+	}
+
+	client, err := arm.NewClient(armModule, armVersion, cred, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not create azure resource manager client: %v", err)
+	}
+	s.arm = client
+
+	return client, nil
+}
+
+// armGet fetches a resource, a resource that does not exist is returned as nil.
+func (s *Sentinel) armGet(ctx context.Context, id, apiVersion string) (armResource, error) {
+	resp, err := s.armDo(ctx, http.MethodGet, id, apiVersion, nil)
+	if err != nil {
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("could not get '%s': %w", id, err)
+	}
+
+	return resp, nil
+}
+
+// armPut creates or updates a resource and waits for ARM to finish provisioning it.
+func (s *Sentinel) armPut(ctx context.Context, id, apiVersion string, body armResource) (armResource, error) {
+	resp, err := s.armDo(ctx, http.MethodPut, id, apiVersion, body)
+	if err != nil {
+		return nil, fmt.Errorf("could not put '%s': %v", id, err)
+	}
+
+	// an accepted PUT may answer without a body, the resource is polled until it settles
+	deadline := time.Now().Add(armPollTimeout)
+	for len(resp) == 0 || !provisioned(resp) {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("'%s' is still provisioning after %s", id, armPollTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(armPollInterval):
+		}
+
+		if resp, err = s.armGet(ctx, id, apiVersion); err != nil {
+			return nil, err
+		}
+		if resp == nil {
+			return nil, fmt.Errorf("'%s' disappeared while provisioning", id)
+		}
+	}
+
+	if state := provisioningState(resp); state != "" && !strings.EqualFold(state, "Succeeded") {
+		return nil, fmt.Errorf("provisioning '%s' ended in state %s", id, state)
+	}
+
+	return resp, nil
+}
+
+func (s *Sentinel) armDo(ctx context.Context, method, id, apiVersion string, body armResource) (armResource, error) {
+	client, err := s.armClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, armTimeout)
+	defer cancel()
+
+	req, err := runtime.NewRequest(ctx, method, runtime.JoinPaths(client.Endpoint(), id))
+	if err != nil {
+		return nil, err
+	}
+
+	query := req.Raw().URL.Query()
+	query.Set("api-version", apiVersion)
+	req.Raw().URL.RawQuery = query.Encode()
+	req.Raw().Header.Set("Accept", "application/json")
+
+	if body != nil {
+		if err := runtime.MarshalAsJSON(req, body); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := client.Pipeline().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !runtime.HasStatusCode(resp, http.StatusOK, http.StatusCreated, http.StatusAccepted) {
+		return nil, runtime.NewResponseError(resp)
+	}
+
+	result := armResource{}
+	if err := runtime.UnmarshalAsJSON(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// provisioned reports whether ARM is done with a resource, resources without a state are done right away.
+func provisioned(resource armResource) bool {
+	switch strings.ToLower(provisioningState(resource)) {
+	case "accepted", "creating", "updating", "inprogress", "provisioning":
+		return false
+	}
+
+	return true
+}
+
+func provisioningState(resource armResource) string {
+	properties, _ := resource["properties"].(map[string]interface{})
+	state, _ := properties["provisioningState"].(string)
+
+	return state
+}
+
+// standInCredential satisfies the ARM pipeline when talking to a local stand-in.
+type standInCredential struct{}
+
+func (standInCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "stand-in", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
@@ -0,0 +1,59 @@
+package sentinel
+
+import (
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/monitor/ingestion/azlogs"
+)
+
+const (
+	CloudPublic = "public"
+	CloudUSGov  = "usgov"
+	CloudChina  = "china"
+	CloudCustom = "custom"
+)
+
+// CloudNames lists the supported Azure clouds.
+var CloudNames = []string{CloudPublic, CloudUSGov, CloudChina, CloudCustom}
+
+// CustomCloud holds the endpoints of a cloud that is not built in, such as Azure Stack Hub.
+type CustomCloud struct {
+	AuthorityHost     string
+	IngestionAudience string
+	ARMEndpoint       string
+	ARMAudience       string
+}
+
+// CloudConfiguration returns the authority host, Logs Ingestion audience and ARM endpoint of a cloud,
+// so the credential, ingestion and ARM clients all talk to the same cloud.
+func CloudConfiguration(name string, custom CustomCloud) (cloud.Configuration, error) {
+	switch name {
+	case "", CloudPublic:
+		return newCloud("https://login.microsoftonline.com/", "https://monitor.azure.com/",
+			"https://management.azure.com/", "https://management.core.windows.net/"), nil
+	case CloudUSGov:
+		return newCloud("https://login.microsoftonline.us/", "https://monitor.azure.us/",
+			"https://management.usgovcloudapi.net/", "https://management.core.usgovcloudapi.net/"), nil
+	case CloudChina:
+		return newCloud("https://login.chinacloudapi.cn/", "https://monitor.azure.cn/",
+			"https://management.chinacloudapi.cn/", "https://management.core.chinacloudapi.cn/"), nil
+	case CloudCustom:
+		if custom.AuthorityHost == "" || custom.IngestionAudience == "" || custom.ARMEndpoint == "" || custom.ARMAudience == "" {
+			return cloud.Configuration{}, fmt.Errorf("a custom cloud needs an authority host, ingestion audience, ARM endpoint and ARM audience")
+		}
+
+		return newCloud(custom.AuthorityHost, custom.IngestionAudience, custom.ARMEndpoint, custom.ARMAudience), nil
+	}
+
+	return cloud.Configuration{}, fmt.Errorf("unknown cloud '%s', should be one of %v", name, CloudNames)
+}
+
+func newCloud(authorityHost, ingestionAudience, armEndpoint, armAudience string) cloud.Configuration {
+	return cloud.Configuration{
+		ActiveDirectoryAuthorityHost: authorityHost,
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+			azlogs.ServiceNameIngestion: {Audience: ingestionAudience},
+			cloud.ResourceManager:       {Endpoint: armEndpoint, Audience: armAudience},
+		},
+	}
+}
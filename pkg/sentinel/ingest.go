@@ -2,8 +2,10 @@ package sentinel
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/monitor/ingestion/azlogs"
 	"github.com/sirupsen/logrus"
 	"time"
@@ -14,32 +16,95 @@ const (
 )
 
 func (s *Sentinel) IngestLog(ctx context.Context, endpoint, ruleID, streamName string, logs []map[string]string) error {
-	logger := s.logger.WithField("module", "sentinel_ingest")
+	b, err := newBatch(logs)
+	if err != nil {
+		return err
+	}
+
+	return s.upload(ctx, endpoint, ruleID, streamName, b)
+}
+
+// ingestClient returns the ingestion client of a data collection endpoint, clients are created once and reused.
+func (s *Sentinel) ingestClient(endpoint string) (*azlogs.Client, error) {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
 
-	ingest, err := azlogs.NewClient(endpoint, s.azCreds, nil)
+	if ingest, ok := s.clients[endpoint]; ok {
+		return ingest, nil
+	}
+
+	// retries are handled by upload so they honor the attempt budget and are logged
+	ingest, err := azlogs.NewClient(endpoint, s.azCreds, &azlogs.ClientOptions{
+		ClientOptions: azcore.ClientOptions{
+			Cloud: s.creds.Cloud,
+			Retry: policy.RetryOptions{MaxRetries: -1},
+		},
+	})
 	if err != nil {
-		return fmt.Errorf("could not create azure ingest client: %v", err)
+		return nil, fmt.Errorf("could not create azure ingest client: %v", err)
+	}
+	s.clients[endpoint] = ingest
+
+	return ingest, nil
+}
+
+// upload sends a batch and retries transient failures with exponential backoff until the attempt budget is spent.
+func (s *Sentinel) upload(ctx context.Context, endpoint, ruleID, streamName string, b batch) error {
+	logger := s.logger.WithField("module", "sentinel_ingest").WithField("stream", streamName)
+
+	for attempt := 1; ; attempt++ {
+		err := s.uploadOnce(ctx, endpoint, ruleID, streamName, b)
+		if err == nil {
+			return nil
+		}
+
+		var uploadErr *UploadError
+		if errors.As(err, &uploadErr) {
+			uploadErr.Attempts = attempt
+		}
+
+		if !retriable(err) {
+			return fmt.Errorf("could not upload logs: %w", err)
+		}
+
+		if attempt >= s.uploadAttempts {
+			return fmt.Errorf("could not upload logs after %d attempts: %w", attempt, err)
+		}
+
+		delay := backoff(attempt, err)
+		logger.WithError(err).WithField("attempt", attempt).WithField("retry_in", delay.String()).
+			Warn("upload failed, retrying")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("could not upload logs: %v", ctx.Err())
+		case <-time.After(delay):
+		}
 	}
+}
 
-	logPayload, err := json.Marshal(&logs)
+func (s *Sentinel) uploadOnce(ctx context.Context, endpoint, ruleID, streamName string, b batch) error {
+	logger := s.logger.WithField("module", "sentinel_ingest")
+
+	ingest, err := s.ingestClient(endpoint)
 	if err != nil {
-		return fmt.Errorf("could not json encode log message: %v", err)
+		return err
 	}
 
 	if s.logger.IsLevelEnabled(logrus.TraceLevel) {
-		logger.Tracef("%s", string(logPayload))
+		logger.Tracef("%s", string(b.payload))
 	}
 
-	logger.WithField("total", len(logs)).Debug("uploading logs")
+	logger.WithField("total", len(b.logs)).Debug("uploading logs")
 
-	ctx, _ = context.WithTimeout(ctx, ingestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, ingestTimeout)
+	defer cancel()
 
-	_, err = ingest.Upload(ctx, ruleID, streamName, logPayload, nil)
-	if err != nil {
-		return fmt.Errorf("could not upload logs: %v", err)
+	if _, err = ingest.Upload(ctx, ruleID, streamName, b.payload, nil); err != nil {
+		return newUploadError(err)
 	}
 
-	logger.WithField("total_logs", len(logs)).Debug("successfully uploaded gong logs")
+	logger.WithField("total_logs", len(b.logs)).Debug("successfully uploaded gong logs")
 
 	return nil
 }
@@ -0,0 +1,161 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+const (
+	PlanAnalytics = "Analytics"
+	PlanBasic     = "Basic"
+	PlanAuxiliary = "Auxiliary"
+
+	// MinRetentionDays and MaxRetentionDays bound the interactive retention of Analytics tables.
+	MinRetentionDays = 4
+	MaxRetentionDays = 730
+	// MaxTotalRetentionDays is the longest a table can keep data including long-term retention.
+	MaxTotalRetentionDays = 4383
+	// fixedRetentionDays is the interactive retention of Basic and Auxiliary tables, it cannot be changed.
+	fixedRetentionDays = 30
+)
+
+// TablePlans lists the supported table plans.
+var TablePlans = []string{PlanAnalytics, PlanBasic, PlanAuxiliary}
+
+// TableLifecycle is the plan and retention of a custom table.
+type TableLifecycle struct {
+	// Plan is the table plan, Analytics when left empty.
+	Plan string
+	// RetentionDays is the interactive retention, only Analytics tables can change it.
+	RetentionDays int
+	// TotalRetentionDays includes long-term retention, it defaults to the interactive retention.
+	TotalRetentionDays int
+}
+
+// Validate checks the lifecycle against the limits of Log Analytics.
+func (t TableLifecycle) Validate() error {
+	retentionDays := t.interactiveRetentionDays()
+
+	switch t.plan() {
+	case PlanAnalytics:
+		if t.RetentionDays < MinRetentionDays || t.RetentionDays > MaxRetentionDays {
+			return fmt.Errorf("retention should be between %d and %d days: %d", MinRetentionDays, MaxRetentionDays, t.RetentionDays)
+		}
+	case PlanBasic, PlanAuxiliary:
+	default:
+		return fmt.Errorf("unknown table plan '%s', should be one of %v", t.Plan, TablePlans)
+	}
+
+	if t.TotalRetentionDays != 0 && (t.TotalRetentionDays < retentionDays || t.TotalRetentionDays > MaxTotalRetentionDays) {
+		return fmt.Errorf("total retention should be between %d and %d days: %d", retentionDays, MaxTotalRetentionDays, t.TotalRetentionDays)
+	}
+
+	return nil
+}
+
+func (t TableLifecycle) plan() string {
+	if t.Plan == "" {
+		return PlanAnalytics
+	}
+
+	return t.Plan
+}
+
+func (t TableLifecycle) interactiveRetentionDays() int {
+	if t.plan() != PlanAnalytics {
+		return fixedRetentionDays
+	}
+
+	return t.RetentionDays
+}
+
+func (t TableLifecycle) totalRetentionDays() int {
+	if t.TotalRetentionDays == 0 {
+		return t.interactiveRetentionDays()
+	}
+
+	return t.TotalRetentionDays
+}
+
+// properties returns the table properties the lifecycle sets.
+func (t TableLifecycle) properties() armResource {
+	properties := armResource{
+		"plan":                 t.plan(),
+		"totalRetentionInDays": t.totalRetentionDays(),
+	}
+
+	// the interactive retention of other plans is fixed and rejected when set
+	if t.plan() == PlanAnalytics {
+		properties["retentionInDays"] = t.RetentionDays
+	}
+
+	return properties
+}
+
+// checkPlanChange rejects plan changes Log Analytics does not allow, Auxiliary is fixed when the table is created.
+func checkPlanChange(name string, desired, current armResource) error {
+	desiredProperties, _ := desired["properties"].(map[string]interface{})
+	currentProperties, _ := current["properties"].(map[string]interface{})
+
+	desiredPlan, _ := desiredProperties["plan"].(string)
+	currentPlan, _ := currentProperties["plan"].(string)
+
+	if currentPlan == "" || strings.EqualFold(desiredPlan, currentPlan) {
+		return nil
+	}
+
+	if strings.EqualFold(desiredPlan, PlanAuxiliary) || strings.EqualFold(currentPlan, PlanAuxiliary) {
+		return fmt.Errorf("the plan of table '%s' cannot be changed from %s to %s, the table has to be recreated",
+			name, currentPlan, desiredPlan)
+	}
+
+	return nil
+}
+
+// ErrDriftCheckUnavailable is returned by CheckTableDrift when the identity may not read the tables,
+// such as an identity that is only allowed to ingest.
+var ErrDriftCheckUnavailable = errors.New("the identity has no read access to the workspace tables")
+
+// CheckTableDrift compares the custom tables of spec with the workspace and logs every difference,
+// such as a retention or plan changed in the portal. It returns the tables that drifted.
+func (s *Sentinel) CheckTableDrift(ctx context.Context, l *logrus.Logger, spec ProvisionSpec) ([]Change, error) {
+	logger := l.WithField("module", "sentinel_provision")
+
+	changes, err := s.planChanges(ctx, tableChanges(spec))
+	if err != nil {
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusForbidden {
+			return nil, ErrDriftCheckUnavailable
+		}
+
+		return nil, err
+	}
+
+	var drifted []Change
+	for _, change := range changes {
+		switch change.Action {
+		case ActionCreate:
+			logger.WithField("table", change.Name).Warn("table does not exist in the workspace, run provision")
+		case ActionUpdate:
+			for _, field := range change.Fields {
+				logger.WithField("table", change.Name).WithField("field", field).
+					Warn("table differs from the configuration, run provision")
+			}
+		default:
+			continue
+		}
+
+		drifted = append(drifted, change)
+	}
+
+	if len(drifted) == 0 {
+		logger.WithField("total", len(changes)).Debug("tables match the configuration")
+	}
+
+	return drifted, nil
+}
@@ -0,0 +1,59 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckTableDrift(t *testing.T) {
+	ctx := context.Background()
+	s := newARMSentinel(t)
+
+	// a missing table is reported as drift
+	drifted, err := s.CheckTableDrift(ctx, discardLogger(), testSpec(TableLifecycle{Plan: PlanAnalytics, RetentionDays: 90}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drifted) != 1 || drifted[0].Action != ActionCreate {
+		t.Fatalf("drifted = %v, want the table to create", drifted)
+	}
+
+	changes, err := s.PlanProvision(ctx, testSpec(TableLifecycle{Plan: PlanAnalytics, RetentionDays: 90}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ApplyProvision(ctx, discardLogger(), changes); err != nil {
+		t.Fatal(err)
+	}
+
+	drifted, err = s.CheckTableDrift(ctx, discardLogger(), testSpec(TableLifecycle{Plan: PlanAnalytics, RetentionDays: 90}))
+	if err != nil || len(drifted) != 0 {
+		t.Fatalf("CheckTableDrift() = %v, %v, want no drift", drifted, err)
+	}
+
+	drifted, err = s.CheckTableDrift(ctx, discardLogger(), testSpec(TableLifecycle{Plan: PlanAnalytics, RetentionDays: 30}))
+	if err != nil || len(drifted) != 1 || drifted[0].Action != ActionUpdate {
+		t.Fatalf("CheckTableDrift() = %v, %v, want the retention to drift", drifted, err)
+	}
+}
+
+func TestCheckTableDriftForbidden(t *testing.T) {
+	// an identity that may only ingest gets a 403 from Resource Manager
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":{"code":"AuthorizationFailed","message":"no read access"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	s := &Sentinel{logger: discardLogger()}
+	s.UseARMStandIn(server.URL)
+
+	_, err := s.CheckTableDrift(context.Background(), discardLogger(), testSpec(TableLifecycle{Plan: PlanAnalytics, RetentionDays: 90}))
+	if !errors.Is(err, ErrDriftCheckUnavailable) {
+		t.Fatalf("CheckTableDrift() error = %v, want ErrDriftCheckUnavailable", err)
+	}
+}
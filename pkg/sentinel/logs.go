@@ -1,49 +1,205 @@
 package sentinel
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"sync"
 )
 
 const (
-	logsPerRequest = 10
+	// MaxRequestBytes is the Logs Ingestion API limit for the body of a single upload.
+	MaxRequestBytes = 1024 * 1024
+
+	// DefaultBatchBytes keeps some headroom below MaxRequestBytes.
+	DefaultBatchBytes = 1000 * 1000
+
+	// DefaultUploadWorkers is the number of batches uploaded concurrently.
+	DefaultUploadWorkers = 4
 )
 
-func chunkLogs(slice []map[string]string, chunkSize int) [][]map[string]string {
-	var chunks [][]map[string]string
-	for i := 0; i < len(slice); i += chunkSize {
-		end := i + chunkSize
+// batch is a set of logs and their JSON array encoding, uploaded in a single request.
+type batch struct {
+	logs    []map[string]string
+	payload []byte
+}
+
+// batchLogs groups logs into batches whose encoded size stays within maxBytes,
+// a positive maxRecords additionally caps the number of logs per batch.
+// Logs that do not fit in a batch on their own are returned as rejected records.
+func batchLogs(logs []map[string]string, maxBytes, maxRecords int) ([]batch, []RejectedRecord, error) {
+	var batches []batch
+	var oversized []RejectedRecord
+
+	var current []map[string]string
+	buf := &bytes.Buffer{}
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+
+		buf.WriteByte(']')
+		batches = append(batches, batch{logs: current, payload: bytes.Clone(buf.Bytes())})
+
+		current = nil
+		buf.Reset()
+	}
+
+	for _, log := range logs {
+		encoded, err := json.Marshal(log)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not json encode log message: %v", err)
+		}
+
+		// the brackets of the JSON array are counted for a batch holding only this log
+		if len(encoded)+2 > maxBytes {
+			oversized = append(oversized, RejectedRecord{
+				Log: log,
+				Err: fmt.Errorf("log is %d bytes which exceeds the batch limit of %d bytes", len(encoded)+2, maxBytes),
+			})
+			continue
+		}
+
+		// a separating comma and the closing bracket are added on top of the current payload
+		full := buf.Len()+len(encoded)+2 > maxBytes
+		if full || (maxRecords > 0 && len(current) >= maxRecords) {
+			flush()
+		}
 
-		// avoid slicing beyond slice capacity
-		if end > len(slice) {
-			end = len(slice)
+		if buf.Len() == 0 {
+			buf.WriteByte('[')
+		} else {
+			buf.WriteByte(',')
 		}
+		buf.Write(encoded)
+		current = append(current, log)
+	}
 
-		chunks = append(chunks, slice[i:end])
+	flush()
+
+	return batches, oversized, nil
+}
+
+// SetBatchLimits changes the maximum encoded size of an upload and optionally the maximum number of logs in it,
+// zero keeps the default size and does not limit the number of logs.
+func (s *Sentinel) SetBatchLimits(maxBytes, maxRecords int) {
+	s.maxBatchBytes = DefaultBatchBytes
+	if maxBytes > 0 && maxBytes <= MaxRequestBytes {
+		s.maxBatchBytes = maxBytes
 	}
 
-	return chunks
+	s.maxBatchRecords = maxRecords
+}
+
+// SetUploadWorkers changes the number of batches uploaded concurrently, zero keeps the default.
+func (s *Sentinel) SetUploadWorkers(workers int) {
+	s.uploadWorkers = DefaultUploadWorkers
+	if workers > 0 {
+		s.uploadWorkers = workers
+	}
 }
 
+// SendLogs ships logs to a data collection rule stream. When Azure rejects individual logs
+// all others are still shipped and a *RejectedError lists the rejected ones.
 func (s *Sentinel) SendLogs(ctx context.Context, l *logrus.Logger, endpoint, ruleID, streamName string, logs []map[string]string) error {
 	logger := l.WithField("module", "sentinel_logs")
 
 	logger.WithField("total", len(logs)).Info("shipping logs")
 
-	chunkedLogs := chunkLogs(logs, logsPerRequest)
-	for i, logsChunk := range chunkedLogs {
-		l.WithField("progress", fmt.Sprintf("%d/%d", i+1, len(chunkedLogs))).Debug("ingesting log chunks")
+	batches, oversized, err := batchLogs(logs, s.maxBatchBytes, s.maxBatchRecords)
+	if err != nil {
+		return fmt.Errorf("could not batch logs: %v", err)
+	}
 
-		if len(logsChunk) == 0 {
-			l.Warn("processing empty chunk")
-			continue
+	return s.sendBatches(ctx, l, endpoint, ruleID, streamName, len(logs), batches, oversized)
+}
+
+// sendBatches uploads batches concurrently, spooling each one until it is uploaded.
+// The oversized logs are dead-lettered right away and reported as rejected along with those Azure refused.
+func (s *Sentinel) sendBatches(ctx context.Context, l *logrus.Logger, endpoint, ruleID, streamName string, total int,
+	batches []batch, oversized []RejectedRecord) error {
+	logger := l.WithField("module", "sentinel_logs")
+
+	if err := s.rejectLogs(endpoint, ruleID, streamName, oversized); err != nil {
+		logger.WithError(err).Error("could not update spool")
+	}
+
+	workers := s.uploadWorkers
+	if workers > len(batches) {
+		workers = len(batches)
+	}
+
+	jobs := make(chan int)
+	errs := make([]error, len(batches))
+	rejected := make([][]RejectedRecord, len(batches))
+	wg := &sync.WaitGroup{}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				b := batches[i]
+				l.WithField("progress", fmt.Sprintf("%d/%d", i+1, len(batches))).
+					WithField("bytes", len(b.payload)).Debug("ingesting log batches")
+
+				// a batch that cannot be spooled is still uploaded, it is only lost if that fails as well
+				id, err := s.spoolBatch(endpoint, ruleID, streamName, b)
+				if err != nil {
+					logger.WithError(err).Error("could not spool batch, uploading it without the spool")
+				}
+
+				var unshipped []map[string]string
+				rejected[i], unshipped, errs[i] = s.uploadBisect(ctx, endpoint, ruleID, streamName, b)
+
+				if err := s.settleBatch(id, endpoint, ruleID, streamName, rejected[i], unshipped, errs[i]); err != nil {
+					logger.WithError(err).WithField("entry", id).Error("could not update spool")
+				}
+			}
+		}()
+	}
+
+	for i := range batches {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	rejectedErr := &RejectedError{Stream: streamName}
+	for _, records := range append([][]RejectedRecord{oversized}, rejected...) {
+		for _, record := range records {
+			// the log itself may hold personal data, it is kept in the dead-letter entry instead
+			encoded, _ := json.Marshal(record.Log)
+			logger.WithError(record.Err).WithField("stream", streamName).WithField("bytes", len(encoded)).
+				WithField("entry", record.SpoolID).Error("log rejected by Sentinel")
+
+			rejectedErr.Records = append(rejectedErr.Records, record)
 		}
+	}
 
-		if err := s.IngestLog(ctx, endpoint, ruleID, streamName, logsChunk); err != nil {
-			return fmt.Errorf("could not ingest log: %v", err)
+	// every batch is attempted, the error reports how many of them failed
+	var failed int
+	var firstErr error
+	for _, err := range errs {
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
 		}
 	}
+	if firstErr != nil {
+		return fmt.Errorf("could not ingest %d of %d log batches: %v", failed, len(batches), firstErr)
+	}
+	if len(rejectedErr.Records) > 0 {
+		logger.WithField("total", total-len(rejectedErr.Records)).WithField("rejected", len(rejectedErr.Records)).
+			Warn("shipped logs except rejected ones")
+		return rejectedErr
+	}
 
 	logger.Info("shipped logs")
 
@@ -0,0 +1,157 @@
+package sentinel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/spool"
+	"io"
+	"strings"
+	"testing"
+)
+
+func discardLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return logger
+}
+
+func testLogs(sizes ...int) []map[string]string {
+	var logs []map[string]string
+	for _, size := range sizes {
+		logs = append(logs, map[string]string{"v": strings.Repeat("x", size)})
+	}
+
+	return logs
+}
+
+func TestBatchLogs(t *testing.T) {
+	// a log with a value of n bytes encodes to n+8 bytes: {"v":"..."}
+	tests := []struct {
+		name         string
+		logs         []map[string]string
+		maxBytes     int
+		maxRecords   int
+		wantBatches  []int
+		wantRejected int
+	}{
+		{
+			name:        "single batch",
+			logs:        testLogs(10, 10, 10),
+			maxBytes:    1000,
+			wantBatches: []int{3},
+		},
+		{
+			name:     "byte limit",
+			logs:     testLogs(10, 10, 10),
+			maxBytes: 2 + 18 + 1 + 18,
+			// two logs fill the batch exactly, brackets and comma included
+			wantBatches: []int{2, 1},
+		},
+		{
+			name:        "byte limit exceeded by one",
+			logs:        testLogs(10, 10, 10),
+			maxBytes:    2 + 18 + 1 + 18 - 1,
+			wantBatches: []int{1, 1, 1},
+		},
+		{
+			name:        "record limit",
+			logs:        testLogs(1, 1, 1, 1, 1),
+			maxBytes:    1000,
+			maxRecords:  2,
+			wantBatches: []int{2, 2, 1},
+		},
+		{
+			name:         "oversized log",
+			logs:         testLogs(10, 100, 10),
+			maxBytes:     50,
+			wantBatches:  []int{2},
+			wantRejected: 1,
+		},
+		{
+			name:         "only oversized logs",
+			logs:         testLogs(100, 100),
+			maxBytes:     50,
+			wantRejected: 2,
+		},
+		{
+			name:     "no logs",
+			maxBytes: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batches, rejected, err := batchLogs(tt.logs, tt.maxBytes, tt.maxRecords)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(batches) != len(tt.wantBatches) {
+				t.Fatalf("got %d batches, want %d", len(batches), len(tt.wantBatches))
+			}
+
+			for i, b := range batches {
+				if len(b.logs) != tt.wantBatches[i] {
+					t.Errorf("batch %d holds %d logs, want %d", i, len(b.logs), tt.wantBatches[i])
+				}
+
+				if len(b.payload) > tt.maxBytes {
+					t.Errorf("batch %d is %d bytes, above the limit of %d", i, len(b.payload), tt.maxBytes)
+				}
+
+				var decoded []map[string]string
+				if err := json.Unmarshal(b.payload, &decoded); err != nil {
+					t.Fatalf("batch %d is not a JSON array: %v", i, err)
+				}
+				if len(decoded) != len(b.logs) {
+					t.Errorf("batch %d payload holds %d logs, want %d", i, len(decoded), len(b.logs))
+				}
+			}
+
+			if len(rejected) != tt.wantRejected {
+				t.Fatalf("got %d rejected logs, want %d", len(rejected), tt.wantRejected)
+			}
+			for _, record := range rejected {
+				if record.Err == nil || len(record.Log["v"]) != 100 {
+					t.Errorf("unexpected rejected record: %v", record)
+				}
+			}
+		})
+	}
+}
+
+func TestSendLogsDeadLettersOversizedLogs(t *testing.T) {
+	sp, err := spool.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Sentinel{logger: discardLogger(), maxBatchBytes: 50, uploadWorkers: 1, spool: sp}
+
+	// no batch is left to upload, so no Azure client is needed
+	err = s.SendLogs(context.Background(), discardLogger(), "https://dce.example.com", "dcr-1", "Custom-Test", testLogs(100))
+
+	var rejectedErr *RejectedError
+	if !errors.As(err, &rejectedErr) || len(rejectedErr.Records) != 1 {
+		t.Fatalf("got %v, want one rejected log", err)
+	}
+
+	ids, err := sp.List(spool.DeadLetter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 {
+		t.Fatalf("got %d dead-lettered entries, want 1", len(ids))
+	}
+
+	entry, err := sp.Read(spool.DeadLetter, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Stream != "Custom-Test" || len(entry.Logs) != 1 || entry.Error == "" {
+		t.Fatalf("unexpected dead-letter entry: %+v", entry)
+	}
+}
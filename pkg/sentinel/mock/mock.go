@@ -0,0 +1,235 @@
+package mock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// defaultRetentionDays is the retention of a workspace without a configured one
+	defaultRetentionDays = 30
+	// fixedRetentionDays is the interactive retention of Basic and Auxiliary tables
+	fixedRetentionDays = 30
+)
+
+// Options configures the Log Analytics workspace the stand-in starts with.
+type Options struct {
+	SubscriptionID string
+	ResourceGroup  string
+	WorkspaceName  string
+	Location       string
+}
+
+// Server is an http.Handler standing in for the Azure Resource Manager. It stores any resource
+// PUT to it, enough to provision the custom tables, data collection endpoint and rule locally.
+type Server struct {
+	logger *logrus.Entry
+
+	mu        sync.Mutex
+	resources map[string]map[string]interface{}
+}
+
+func New(logger *logrus.Logger, opts Options) *Server {
+	s := &Server{
+		logger:    logger.WithField("module", "arm_mock"),
+		resources: map[string]map[string]interface{}{},
+	}
+
+	workspaceID := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.OperationalInsights/workspaces/%s",
+		opts.SubscriptionID, opts.ResourceGroup, opts.WorkspaceName)
+	s.store(workspaceID, map[string]interface{}{
+		"location": opts.Location,
+		"properties": map[string]interface{}{
+			"customerId": uuidFromID(workspaceID),
+		},
+	})
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := s.logger.WithField("method", r.Method).WithField("path", r.URL.Path)
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		logger.Warn("rejecting unauthenticated request")
+		s.writeError(w, http.StatusUnauthorized, "AuthenticationFailed", "Authentication failed, the Authorization header is missing.")
+		return
+	}
+
+	if r.URL.Query().Get("api-version") == "" {
+		s.writeError(w, http.StatusBadRequest, "MissingApiVersionParameter", "The api-version query parameter (?api-version=) is required for all requests.")
+		return
+	}
+
+	logger.Debug("serving request")
+
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGet(w, r)
+	case http.MethodPut:
+		s.handlePut(w, r)
+	default:
+		s.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
+	}
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	resource, ok := s.resources[strings.ToLower(r.URL.Path)]
+	s.mu.Unlock()
+
+	if !ok {
+		s.writeError(w, http.StatusNotFound, "ResourceNotFound", fmt.Sprintf("The resource '%s' was not found.", r.URL.Path))
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, resource)
+}
+
+func (s *Server) handlePut(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.writeError(w, http.StatusBadRequest, "InvalidRequestContent", fmt.Sprintf("The request content was invalid: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.resources[strings.ToLower(r.URL.Path)]
+	if exists {
+		if err := checkTableUpdate(current, body); err != nil {
+			s.writeError(w, http.StatusBadRequest, "InvalidParameter", err.Error())
+			return
+		}
+	}
+	resource := s.store(r.URL.Path, body)
+
+	status := http.StatusOK
+	if !exists {
+		status = http.StatusCreated
+	}
+
+	s.writeJSON(w, status, resource)
+}
+
+// store saves a resource with the fields ARM adds, the caller holds the lock unless the server is not serving yet.
+func (s *Server) store(id string, resource map[string]interface{}) map[string]interface{} {
+	segments := strings.Split(strings.Trim(id, "/"), "/")
+
+	resource["id"] = id
+	resource["name"] = segments[len(segments)-1]
+	resource["type"] = resourceType(segments)
+
+	properties, _ := resource["properties"].(map[string]interface{})
+	if properties == nil {
+		properties = map[string]interface{}{}
+		resource["properties"] = properties
+	}
+	properties["provisioningState"] = "Succeeded"
+
+	switch strings.ToLower(resource["type"].(string)) {
+	case "microsoft.insights/datacollectionendpoints":
+		location, _ := resource["location"].(string)
+		properties["logsIngestion"] = map[string]interface{}{
+			"endpoint": fmt.Sprintf("https://%s-%s.%s-1.ingest.monitor.azure.com",
+				strings.ToLower(resource["name"].(string)), hash(id)[:4], location),
+		}
+	case "microsoft.insights/datacollectionrules":
+		properties["immutableId"] = "dcr-" + hash(id)[:32]
+	case "microsoft.operationalinsights/workspaces/tables":
+		setTableDefaults(properties)
+	}
+
+	s.resources[strings.ToLower(id)] = resource
+
+	return resource
+}
+
+// setTableDefaults fills in the plan and retention Log Analytics uses when a table does not set them.
+func setTableDefaults(properties map[string]interface{}) {
+	if _, ok := properties["plan"]; !ok {
+		properties["plan"] = "Analytics"
+	}
+
+	// only Analytics tables can change their interactive retention
+	if properties["plan"] != "Analytics" {
+		properties["retentionInDays"] = float64(fixedRetentionDays)
+	}
+	if _, ok := properties["retentionInDays"]; !ok {
+		properties["retentionInDays"] = float64(defaultRetentionDays)
+	}
+	if _, ok := properties["totalRetentionInDays"]; !ok {
+		properties["totalRetentionInDays"] = properties["retentionInDays"]
+	}
+
+	retention, _ := properties["retentionInDays"].(float64)
+	total, _ := properties["totalRetentionInDays"].(float64)
+	properties["archiveRetentionInDays"] = total - retention
+}
+
+// checkTableUpdate rejects switching a table from or to the Auxiliary plan, like Log Analytics does.
+func checkTableUpdate(current, update map[string]interface{}) error {
+	currentProperties, _ := current["properties"].(map[string]interface{})
+	updateProperties, _ := update["properties"].(map[string]interface{})
+
+	currentPlan, _ := currentProperties["plan"].(string)
+	updatePlan, ok := updateProperties["plan"].(string)
+	if !ok || currentPlan == "" || updatePlan == currentPlan {
+		return nil
+	}
+
+	if currentPlan == "Auxiliary" || updatePlan == "Auxiliary" {
+		return fmt.Errorf("changing the plan of a table from %s to %s is not supported", currentPlan, updatePlan)
+	}
+
+	return nil
+}
+
+// resourceType derives the type, e.g. Microsoft.OperationalInsights/workspaces/tables, from the segments of an ID.
+func resourceType(segments []string) string {
+	for i, segment := range segments {
+		if strings.EqualFold(segment, "providers") && i+1 < len(segments) {
+			parts := []string{segments[i+1]}
+			for j := i + 2; j < len(segments); j += 2 {
+				parts = append(parts, segments[j])
+			}
+			return strings.Join(parts, "/")
+		}
+	}
+
+	return ""
+}
+
+func hash(id string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(id)))
+	return hex.EncodeToString(sum[:])
+}
+
+func uuidFromID(id string) string {
+	h := hash(id)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, code, msg string) {
+	s.writeJSON(w, status, map[string]interface{}{
+		"error": map[string]string{
+			"code":    code,
+			"message": msg,
+		},
+	})
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		s.logger.WithError(err).Error("could not write response")
+	}
+}
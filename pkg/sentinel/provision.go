@@ -0,0 +1,392 @@
+package sentinel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/schema"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionNone   = "none"
+
+	KindTable                  = "table"
+	KindDataCollectionEndpoint = "data collection endpoint"
+	KindDataCollectionRule     = "data collection rule"
+
+	workspaceAPIVersion      = "2022-10-01"
+	tableAPIVersion          = "2022-10-01"
+	dataCollectionAPIVersion = "2022-06-01"
+
+	// workspaceDestination is the name of the workspace destination in the data collection rule
+	workspaceDestination = "gongWorkspace"
+)
+
+// Stream is a data collection rule stream and the custom table it is written to.
+type Stream struct {
+	// Name is the input stream shipped to, e.g. Custom-GongAuditLogs.
+	Name      string
+	Table     schema.Table
+	Lifecycle TableLifecycle
+}
+
+// ProvisionSpec describes the custom tables, data collection endpoint and rule gong2sentinel ships to.
+type ProvisionSpec struct {
+	SubscriptionID string
+	ResourceGroup  string
+	WorkspaceName  string
+	EndpointName   string
+	RuleName       string
+	Streams        []Stream
+}
+
+func (p ProvisionSpec) resourceGroupID() string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", p.SubscriptionID, p.ResourceGroup)
+}
+
+func (p ProvisionSpec) workspaceID() string {
+	return p.resourceGroupID() + "/providers/Microsoft.OperationalInsights/workspaces/" + p.WorkspaceName
+}
+
+func (p ProvisionSpec) endpointID() string {
+	return p.resourceGroupID() + "/providers/Microsoft.Insights/dataCollectionEndpoints/" + p.EndpointName
+}
+
+func (p ProvisionSpec) ruleID() string {
+	return p.resourceGroupID() + "/providers/Microsoft.Insights/dataCollectionRules/" + p.RuleName
+}
+
+// Change is the difference between a desired resource and the one in Azure.
+type Change struct {
+	Kind   string
+	Name   string
+	Action string
+	// Fields lists the differing fields of an update as "path: current -> desired".
+	Fields []string
+
+	id         string
+	apiVersion string
+	desired    armResource
+	current    armResource
+}
+
+// Provisioned holds the values the data collection config needs once provisioning is done.
+type Provisioned struct {
+	// IngestionEndpoint is the logs ingestion endpoint of the data collection endpoint.
+	IngestionEndpoint string
+	// RuleImmutableID is the immutable ID of the data collection rule.
+	RuleImmutableID string
+}
+
+// PlanProvision compares the resources of spec with the ones in Azure, in the order they have to be created.
+func (s *Sentinel) PlanProvision(ctx context.Context, spec ProvisionSpec) ([]Change, error) {
+	workspace, err := s.armGet(ctx, spec.workspaceID(), workspaceAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	if workspace == nil {
+		return nil, fmt.Errorf("log analytics workspace '%s' does not exist", spec.WorkspaceName)
+	}
+
+	// the data collection endpoint and rule live next to the workspace
+	location, _ := workspace["location"].(string)
+	if location == "" {
+		return nil, fmt.Errorf("could not determine the location of workspace '%s'", spec.WorkspaceName)
+	}
+
+	changes := append(tableChanges(spec), Change{
+		Kind:       KindDataCollectionEndpoint,
+		Name:       spec.EndpointName,
+		id:         spec.endpointID(),
+		apiVersion: dataCollectionAPIVersion,
+		desired:    endpointResource(location),
+	}, Change{
+		Kind:       KindDataCollectionRule,
+		Name:       spec.RuleName,
+		id:         spec.ruleID(),
+		apiVersion: dataCollectionAPIVersion,
+		desired:    ruleResource(spec, location),
+	})
+
+	return s.planChanges(ctx, changes)
+}
+
+// tableChanges returns the desired custom tables of spec.
+func tableChanges(spec ProvisionSpec) []Change {
+	var changes []Change
+
+	for _, stream := range spec.Streams {
+		changes = append(changes, Change{
+			Kind:       KindTable,
+			Name:       stream.Table.TableName(),
+			id:         spec.workspaceID() + "/tables/" + stream.Table.TableName(),
+			apiVersion: tableAPIVersion,
+			desired:    tableResource(stream.Table, stream.Lifecycle),
+		})
+	}
+
+	return changes
+}
+
+// planChanges fetches the current resources and sets the action needed to make them match the desired ones.
+// The resources are fetched concurrently so planning takes one ARM round trip however many tables there are.
+func (s *Sentinel) planChanges(ctx context.Context, changes []Change) ([]Change, error) {
+	errs := make([]error, len(changes))
+	wg := &sync.WaitGroup{}
+	for i := range changes {
+		wg.Add(1)
+		go func(change *Change, i int) {
+			defer wg.Done()
+			change.current, errs[i] = s.armGet(ctx, change.id, change.apiVersion)
+		}(&changes[i], i)
+	}
+	wg.Wait()
+
+	for i := range changes {
+		change := &changes[i]
+
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		var err error
+		if change.desired, err = normalizeResource(change.desired); err != nil {
+			return nil, err
+		}
+
+		if change.current == nil {
+			change.Action = ActionCreate
+			continue
+		}
+
+		if change.Kind == KindTable {
+			if err := checkPlanChange(change.Name, change.desired, change.current); err != nil {
+				return nil, err
+			}
+			if err := checkColumnChanges(change.Name, change.desired, change.current); err != nil {
+				return nil, err
+			}
+		}
+
+		change.Fields = diffResource("", change.desired, change.current)
+		change.Action = ActionNone
+		if len(change.Fields) > 0 {
+			change.Action = ActionUpdate
+		}
+	}
+
+	return changes, nil
+}
+
+// ApplyProvision creates or updates the resources of a plan that differ from Azure.
+func (s *Sentinel) ApplyProvision(ctx context.Context, l *logrus.Logger, changes []Change) (Provisioned, error) {
+	logger := l.WithField("module", "sentinel_provision")
+
+	var result Provisioned
+
+	for _, change := range changes {
+		resource := change.current
+
+		if change.Action != ActionNone {
+			logger.WithField("kind", change.Kind).WithField("name", change.Name).WithField("action", change.Action).
+				Info("provisioning resource")
+
+			var err error
+			if resource, err = s.armPut(ctx, change.id, change.apiVersion, change.desired); err != nil {
+				return result, fmt.Errorf("could not %s %s '%s': %v", change.Action, change.Kind, change.Name, err)
+			}
+		}
+
+		properties, _ := resource["properties"].(map[string]interface{})
+
+		switch change.Kind {
+		case KindDataCollectionEndpoint:
+			logsIngestion, _ := properties["logsIngestion"].(map[string]interface{})
+			result.IngestionEndpoint, _ = logsIngestion["endpoint"].(string)
+		case KindDataCollectionRule:
+			result.RuleImmutableID, _ = properties["immutableId"].(string)
+		}
+	}
+
+	return result, nil
+}
+
+func tableResource(table schema.Table, lifecycle TableLifecycle) armResource {
+	properties := lifecycle.properties()
+	properties["schema"] = armResource{
+		"name":        table.TableName(),
+		"description": table.Description,
+		"columns":     table.Columns,
+	}
+
+	return armResource{"properties": properties}
+}
+
+// checkColumnChanges rejects changing the type of an existing column, Log Analytics only allows adding columns.
+// It happens with tables created by hand or by an older release with other column types.
+func checkColumnChanges(name string, desired, current armResource) error {
+	currentTypes := map[string]string{}
+	for _, column := range tableColumns(current) {
+		currentTypes[strings.ToLower(column["name"])] = column["type"]
+	}
+
+	for _, column := range tableColumns(desired) {
+		currentType, ok := currentTypes[strings.ToLower(column["name"])]
+		if ok && !strings.EqualFold(currentType, column["type"]) {
+			return fmt.Errorf("column '%s' of table '%s' is %s in the workspace but %s in gong2sentinel and "+
+				"Log Analytics cannot change column types, recreate the table or migrate its data to a new one",
+				column["name"], name, currentType, column["type"])
+		}
+	}
+
+	return nil
+}
+
+func tableColumns(table armResource) []map[string]string {
+	properties, _ := table["properties"].(map[string]interface{})
+	tableSchema, _ := properties["schema"].(map[string]interface{})
+	columns, _ := tableSchema["columns"].([]interface{})
+
+	var result []map[string]string
+	for _, column := range columns {
+		fields, _ := column.(map[string]interface{})
+		name, _ := fields["name"].(string)
+		columnType, _ := fields["type"].(string)
+		result = append(result, map[string]string{"name": name, "type": columnType})
+	}
+
+	return result
+}
+
+func endpointResource(location string) armResource {
+	return armResource{
+		"location": location,
+		"properties": armResource{
+			"networkAcls": armResource{
+				"publicNetworkAccess": "Enabled",
+			},
+		},
+	}
+}
+
+func ruleResource(spec ProvisionSpec, location string) armResource {
+	declarations := armResource{}
+	var dataFlows []armResource
+
+	for _, stream := range spec.Streams {
+		// records are shipped as text, the transformation converts them to the column types of the table
+		declarations[stream.Name] = armResource{"columns": stream.Table.StreamColumns()}
+
+		dataFlows = append(dataFlows, armResource{
+			"streams":      []string{stream.Name},
+			"destinations": []string{workspaceDestination},
+			"transformKql": stream.Table.TransformKQL(),
+			"outputStream": stream.Table.OutputStream(),
+		})
+	}
+
+	return armResource{
+		"location": location,
+		"properties": armResource{
+			"dataCollectionEndpointId": spec.endpointID(),
+			"streamDeclarations":       declarations,
+			"destinations": armResource{
+				"logAnalytics": []armResource{
+					{"workspaceResourceId": spec.workspaceID(), "name": workspaceDestination},
+				},
+			},
+			"dataFlows": dataFlows,
+		},
+	}
+}
+
+// normalizeResource round trips a resource through JSON so it compares to what ARM returns.
+func normalizeResource(resource armResource) (armResource, error) {
+	encoded, err := json.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode resource: %v", err)
+	}
+
+	normalized := armResource{}
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return nil, fmt.Errorf("could not decode resource: %v", err)
+	}
+
+	return normalized, nil
+}
+
+// diffResource lists the fields of desired that differ from current. Fields ARM adds, such as
+// provisioningState, are ignored and strings compare case-insensitively as ARM does not keep the case of IDs.
+func diffResource(path string, desired, current interface{}) []string {
+	// a missing object or array is reported as a whole instead of leaf by leaf
+	if current == nil {
+		return []string{fmt.Sprintf("%s: %s -> %s", path, formatValue(current), formatValue(desired))}
+	}
+
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		currentValue, _ := current.(map[string]interface{})
+
+		var fields []string
+		for _, key := range sortedKeys(desiredValue) {
+			fields = append(fields, diffResource(joinPath(path, key), desiredValue[key], currentValue[key])...)
+		}
+		return fields
+
+	case []interface{}:
+		currentValue, _ := current.([]interface{})
+
+		var fields []string
+		if len(desiredValue) != len(currentValue) {
+			fields = append(fields, fmt.Sprintf("%s.length: %d -> %d", path, len(currentValue), len(desiredValue)))
+		}
+		for i := range desiredValue {
+			var currentItem interface{}
+			if i < len(currentValue) {
+				currentItem = currentValue[i]
+			}
+			fields = append(fields, diffResource(fmt.Sprintf("%s[%d]", path, i), desiredValue[i], currentItem)...)
+		}
+		return fields
+	}
+
+	desiredString, currentString := formatValue(desired), formatValue(current)
+	if strings.EqualFold(desiredString, currentString) {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("%s: %s -> %s", path, currentString, desiredString)}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func formatValue(value interface{}) string {
+	if value == nil {
+		return "<unset>"
+	}
+
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
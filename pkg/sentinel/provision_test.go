@@ -0,0 +1,280 @@
+package sentinel
+
+import (
+	"context"
+	"encoding/json"
+	"gong2sentinel/pkg/schema"
+	"gong2sentinel/pkg/sentinel/mock"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testRecord struct {
+	TimeGenerated schema.DateTime `json:"TimeGenerated"`
+	Name          string          `json:"name"`
+	Details       json.RawMessage `json:"details"`
+}
+
+var testTable = schema.NewTable("GongTest", "Test records", testRecord{})
+
+func testSpec(lifecycle TableLifecycle) ProvisionSpec {
+	return ProvisionSpec{
+		SubscriptionID: "sub",
+		ResourceGroup:  "rg",
+		WorkspaceName:  "ws",
+		EndpointName:   "gong2sentinel",
+		RuleName:       "gong2sentinel",
+		Streams:        []Stream{{Name: testTable.StreamName(), Table: testTable, Lifecycle: lifecycle}},
+	}
+}
+
+// newARMSentinel returns a Sentinel provisioning against the ARM stand-in.
+func newARMSentinel(t *testing.T) *Sentinel {
+	t.Helper()
+
+	server := httptest.NewServer(mock.New(discardLogger(), mock.Options{
+		SubscriptionID: "sub",
+		ResourceGroup:  "rg",
+		WorkspaceName:  "ws",
+		Location:       "westeurope",
+	}))
+	t.Cleanup(server.Close)
+
+	s := &Sentinel{logger: discardLogger()}
+	s.UseARMStandIn(server.URL)
+
+	return s
+}
+
+func actions(changes []Change) map[string]string {
+	result := map[string]string{}
+	for _, change := range changes {
+		result[change.Kind] = change.Action
+	}
+
+	return result
+}
+
+func TestProvision(t *testing.T) {
+	analytics := TableLifecycle{Plan: PlanAnalytics, RetentionDays: 90}
+
+	tests := []struct {
+		name string
+		// existing is provisioned before the plan is made, nil starts from an empty workspace
+		existing    *ProvisionSpec
+		spec        ProvisionSpec
+		wantActions map[string]string
+		wantFields  []string
+		wantErr     string
+	}{
+		{
+			name: "create",
+			spec: testSpec(analytics),
+			wantActions: map[string]string{
+				KindTable: ActionCreate, KindDataCollectionEndpoint: ActionCreate, KindDataCollectionRule: ActionCreate,
+			},
+		},
+		{
+			name:     "no-op",
+			existing: ptr(testSpec(analytics)),
+			spec:     testSpec(analytics),
+			wantActions: map[string]string{
+				KindTable: ActionNone, KindDataCollectionEndpoint: ActionNone, KindDataCollectionRule: ActionNone,
+			},
+		},
+		{
+			name:     "update retention",
+			existing: ptr(testSpec(analytics)),
+			spec:     testSpec(TableLifecycle{Plan: PlanAnalytics, RetentionDays: 120, TotalRetentionDays: 365}),
+			wantActions: map[string]string{
+				KindTable: ActionUpdate, KindDataCollectionEndpoint: ActionNone, KindDataCollectionRule: ActionNone,
+			},
+			wantFields: []string{
+				"properties.retentionInDays: 90 -> 120",
+				"properties.totalRetentionInDays: 90 -> 365",
+			},
+		},
+		{
+			name:     "switch to basic",
+			existing: ptr(testSpec(analytics)),
+			spec:     testSpec(TableLifecycle{Plan: PlanBasic}),
+			wantActions: map[string]string{
+				KindTable: ActionUpdate, KindDataCollectionEndpoint: ActionNone, KindDataCollectionRule: ActionNone,
+			},
+			wantFields: []string{
+				`properties.plan: "Analytics" -> "Basic"`,
+				"properties.totalRetentionInDays: 90 -> 30",
+			},
+		},
+		{
+			name:     "switch to auxiliary rejected",
+			existing: ptr(testSpec(analytics)),
+			spec:     testSpec(TableLifecycle{Plan: PlanAuxiliary}),
+			wantErr:  "cannot be changed from Analytics to Auxiliary",
+		},
+		{
+			name:     "switch from auxiliary rejected",
+			existing: ptr(testSpec(TableLifecycle{Plan: PlanAuxiliary})),
+			spec:     testSpec(analytics),
+			wantErr:  "cannot be changed from Auxiliary to Analytics",
+		},
+		{
+			name:     "column type change rejected",
+			existing: ptr(withColumnType(testSpec(analytics), "details", schema.TypeString)),
+			spec:     testSpec(analytics),
+			wantErr:  "column 'details' of table 'GongTest_CL' is string in the workspace but dynamic",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newARMSentinel(t)
+
+			if tt.existing != nil {
+				changes, err := s.PlanProvision(ctx, *tt.existing)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := s.ApplyProvision(ctx, discardLogger(), changes); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			changes, err := s.PlanProvision(ctx, tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PlanProvision() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlanProvision() error = %v", err)
+			}
+
+			if got := actions(changes); len(got) != len(tt.wantActions) || !mapsEqual(got, tt.wantActions) {
+				t.Fatalf("actions = %v, want %v", got, tt.wantActions)
+			}
+
+			var fields []string
+			for _, change := range changes {
+				fields = append(fields, change.Fields...)
+			}
+			if strings.Join(fields, "\n") != strings.Join(tt.wantFields, "\n") {
+				t.Fatalf("fields = %q, want %q", fields, tt.wantFields)
+			}
+
+			provisioned, err := s.ApplyProvision(ctx, discardLogger(), changes)
+			if err != nil {
+				t.Fatalf("ApplyProvision() error = %v", err)
+			}
+			if !strings.HasPrefix(provisioned.IngestionEndpoint, "https://") || !strings.HasPrefix(provisioned.RuleImmutableID, "dcr-") {
+				t.Fatalf("unexpected provisioning result: %+v", provisioned)
+			}
+
+			// the applied plan leaves nothing to do
+			again, err := s.PlanProvision(ctx, tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, change := range again {
+				if change.Action != ActionNone {
+					t.Errorf("%s '%s' still needs %s after apply: %v", change.Kind, change.Name, change.Action, change.Fields)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffResource(t *testing.T) {
+	tests := []struct {
+		name    string
+		desired string
+		current string
+		want    []string
+	}{
+		{
+			name:    "equal",
+			desired: `{"properties":{"plan":"Analytics","retentionInDays":90}}`,
+			current: `{"properties":{"plan":"Analytics","retentionInDays":90}}`,
+		},
+		{
+			name:    "fields added by ARM are ignored",
+			desired: `{"properties":{"plan":"Analytics"}}`,
+			current: `{"id":"/x","properties":{"plan":"Analytics","provisioningState":"Succeeded"}}`,
+		},
+		{
+			name:    "strings compare case-insensitively",
+			desired: `{"properties":{"workspaceResourceId":"/subscriptions/sub/resourceGroups/RG"}}`,
+			current: `{"properties":{"workspaceResourceId":"/subscriptions/sub/resourcegroups/rg"}}`,
+		},
+		{
+			name:    "changed value",
+			desired: `{"properties":{"retentionInDays":120}}`,
+			current: `{"properties":{"retentionInDays":90}}`,
+			want:    []string{"properties.retentionInDays: 90 -> 120"},
+		},
+		{
+			name:    "missing object",
+			desired: `{"properties":{"networkAcls":{"publicNetworkAccess":"Enabled"}}}`,
+			current: `{"properties":{}}`,
+			want:    []string{`properties.networkAcls: <unset> -> {"publicNetworkAccess":"Enabled"}`},
+		},
+		{
+			name:    "added column",
+			desired: `{"columns":[{"name":"a","type":"string"},{"name":"b","type":"long"}]}`,
+			current: `{"columns":[{"name":"a","type":"string"}]}`,
+			want: []string{
+				"columns.length: 1 -> 2",
+				`columns[1]: <unset> -> {"name":"b","type":"long"}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var desired, current interface{}
+			if err := json.Unmarshal([]byte(tt.desired), &desired); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.current), &current); err != nil {
+				t.Fatal(err)
+			}
+
+			got := diffResource("", desired, current)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("diffResource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// withColumnType changes the type of a column of the tables of spec, like a table created by hand would have.
+func withColumnType(spec ProvisionSpec, name, columnType string) ProvisionSpec {
+	for i, stream := range spec.Streams {
+		columns := append([]schema.Column{}, stream.Table.Columns...)
+		for j := range columns {
+			if columns[j].Name == name {
+				columns[j].Type = columnType
+			}
+		}
+		spec.Streams[i].Table.Columns = columns
+	}
+
+	return spec
+}
+
+func mapsEqual(a, b map[string]string) bool {
+	for key, value := range a {
+		if b[key] != value {
+			return false
+		}
+	}
+
+	return len(a) == len(b)
+}
+
+func ptr(spec ProvisionSpec) *ProvisionSpec {
+	return &spec
+}
@@ -0,0 +1,113 @@
+package sentinel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// RejectedRecord is a log the Logs Ingestion API refused even when uploaded on its own.
+type RejectedRecord struct {
+	Log map[string]string
+	Err error
+	// SpoolID is the dead-letter entry holding the log, empty when no spool is configured
+	SpoolID string
+}
+
+// RejectedError is returned by SendLogs when every log was shipped except the rejected records.
+type RejectedError struct {
+	Stream  string
+	Records []RejectedRecord
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%d logs rejected by stream %s, first: %v", len(e.Records), e.Stream, e.Records[0].Err)
+}
+
+func newBatch(logs []map[string]string) (batch, error) {
+	payload, err := json.Marshal(logs)
+	if err != nil {
+		return batch{}, fmt.Errorf("could not json encode log message: %v", err)
+	}
+
+	return batch{logs: logs, payload: payload}, nil
+}
+
+// rejection returns the upload error of a batch Azure refused with a 400, nil for other outcomes.
+func rejection(err error) *UploadError {
+	var uploadErr *UploadError
+	if err == nil || !errors.As(err, &uploadErr) || uploadErr.StatusCode != http.StatusBadRequest {
+		return nil
+	}
+
+	return uploadErr
+}
+
+// uploadBisect uploads a batch and, when Azure rejects it with a 400, splits it in halves
+// until the offending logs are isolated so all other logs are still shipped.
+// On error the logs that were neither shipped nor rejected are returned along with it.
+func (s *Sentinel) uploadBisect(ctx context.Context, endpoint, ruleID, streamName string, b batch) ([]RejectedRecord, []map[string]string, error) {
+	err := s.upload(ctx, endpoint, ruleID, streamName, b)
+	if err == nil {
+		return nil, nil, nil
+	}
+	if rejection(err) == nil {
+		return nil, b.logs, err
+	}
+
+	return s.isolateRejected(ctx, endpoint, ruleID, streamName, b, err)
+}
+
+// isolateRejected splits a rejected batch in halves, uploads them and recurses into the rejected ones.
+func (s *Sentinel) isolateRejected(ctx context.Context, endpoint, ruleID, streamName string, b batch,
+	batchErr error) ([]RejectedRecord, []map[string]string, error) {
+	if len(b.logs) == 1 {
+		return []RejectedRecord{{Log: b.logs[0], Err: rejection(batchErr)}}, nil, nil
+	}
+
+	s.logger.WithField("module", "sentinel_ingest").WithField("stream", streamName).WithField("total", len(b.logs)).
+		Debug("batch rejected, splitting it to isolate the offending logs")
+
+	mid := len(b.logs) / 2
+
+	var halves []batch
+	var errs []error
+	for _, logs := range [][]map[string]string{b.logs[:mid], b.logs[mid:]} {
+		half, err := newBatch(logs)
+		if err != nil {
+			return nil, b.logs, err
+		}
+
+		halves = append(halves, half)
+		errs = append(errs, s.upload(ctx, endpoint, ruleID, streamName, half))
+	}
+
+	// unshipped collects, once a half failed, that half and the later ones that were not accepted either
+	var rejected []RejectedRecord
+	var unshipped []map[string]string
+	var failure error
+	for i, half := range halves {
+		if errs[i] == nil {
+			continue
+		}
+
+		if failure != nil || rejection(errs[i]) == nil {
+			unshipped = append(unshipped, half.logs...)
+			if failure == nil {
+				failure = errs[i]
+			}
+			continue
+		}
+
+		halfRejected, halfUnshipped, err := s.isolateRejected(ctx, endpoint, ruleID, streamName, half, errs[i])
+		rejected = append(rejected, halfRejected...)
+		unshipped = append(unshipped, halfUnshipped...)
+		if err != nil {
+			failure = err
+		}
+	}
+
+	return rejected, unshipped, failure
+}
@@ -0,0 +1,218 @@
+package sentinel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/monitor/ingestion/azlogs"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// ingestServer stands in for the Logs Ingestion API, it refuses every batch holding a log with a "bad" field
+// and fails those holding a log with a "fail" field, whichever comes first.
+type ingestServer struct {
+	mu       sync.Mutex
+	uploads  int
+	accepted []map[string]string
+	// status, when set, answers every upload
+	status int
+}
+
+func (i *ingestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.uploads++
+
+	if i.status != 0 {
+		w.WriteHeader(i.status)
+		return
+	}
+
+	var logs []map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&logs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, log := range logs {
+		if _, ok := log["fail"]; ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if bad, ok := log["bad"]; ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, `{"error":{"code":"InvalidArgument","message":"invalid field %s"}}`, bad)
+			return
+		}
+	}
+
+	i.accepted = append(i.accepted, logs...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newIngestSentinel returns a Sentinel uploading to an ingestion stand-in and the endpoint to upload to.
+func newIngestSentinel(t *testing.T, ingest *ingestServer) (*Sentinel, string) {
+	t.Helper()
+
+	// the ingestion client only sends tokens over TLS
+	server := httptest.NewTLSServer(ingest)
+	t.Cleanup(server.Close)
+
+	client, err := azlogs.NewClient(server.URL, standInCredential{}, &azlogs.ClientOptions{
+		ClientOptions: azcore.ClientOptions{
+			Retry:     policy.RetryOptions{MaxRetries: -1},
+			Transport: server.Client(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Sentinel{
+		logger:         discardLogger(),
+		clients:        map[string]*azlogs.Client{server.URL: client},
+		maxBatchBytes:  DefaultBatchBytes,
+		uploadWorkers:  1,
+		uploadAttempts: 1,
+		flushAttempts:  DefaultFlushAttempts,
+	}
+
+	return s, server.URL
+}
+
+// numberedLogs returns n logs, those at the bad indexes carry a "bad" field with the given value.
+func numberedLogs(n int, bad map[int]string) []map[string]string {
+	var logs []map[string]string
+	for i := 0; i < n; i++ {
+		log := map[string]string{"n": fmt.Sprint(i)}
+		if value, ok := bad[i]; ok {
+			log["bad"] = value
+		}
+		logs = append(logs, log)
+	}
+
+	return logs
+}
+
+// withFailure marks the log at index i as one the ingestion stand-in fails on.
+func withFailure(logs []map[string]string, i int) []map[string]string {
+	logs[i]["fail"] = "true"
+	return logs
+}
+
+func TestUploadBisect(t *testing.T) {
+	tests := []struct {
+		name          string
+		logs          []map[string]string
+		status        int
+		wantRejected  []string
+		wantAccepted  int
+		wantUnshipped int
+		wantUploads   int
+		wantErr       bool
+	}{
+		{
+			name:         "accepted",
+			logs:         numberedLogs(8, nil),
+			wantAccepted: 8,
+			wantUploads:  1,
+		},
+		{
+			name:         "single offending log",
+			logs:         numberedLogs(8, map[int]string{5: "a"}),
+			wantRejected: []string{"5"},
+			wantAccepted: 7,
+			// the batch, both halves of every split down to the offending log
+			wantUploads: 7,
+		},
+		{
+			name:         "offending logs with distinct errors",
+			logs:         numberedLogs(8, map[int]string{1: "a", 6: "b"}),
+			wantRejected: []string{"1", "6"},
+			wantAccepted: 6,
+			wantUploads:  11,
+		},
+		{
+			name:         "rejected below the first split with the same error",
+			logs:         numberedLogs(8, map[int]string{0: "a", 1: "a"}),
+			wantRejected: []string{"0", "1"},
+			wantAccepted: 6,
+			wantUploads:  7,
+		},
+		{
+			name:         "both halves of the first split rejected with the same error",
+			logs:         numberedLogs(8, map[int]string{1: "a", 5: "a"}),
+			wantRejected: []string{"1", "5"},
+			wantAccepted: 6,
+			wantUploads:  11,
+		},
+		{
+			name:          "server error",
+			logs:          numberedLogs(8, nil),
+			status:        http.StatusForbidden,
+			wantUnshipped: 8,
+			wantUploads:   1,
+			wantErr:       true,
+		},
+		{
+			name:          "half failing after the other half was isolated",
+			logs:          withFailure(numberedLogs(8, map[int]string{1: "a"}), 6),
+			wantRejected:  []string{"1"},
+			wantAccepted:  3,
+			wantUnshipped: 4,
+			wantUploads:   7,
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingest := &ingestServer{status: tt.status}
+			s, endpoint := newIngestSentinel(t, ingest)
+
+			b, err := newBatch(tt.logs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rejected, unshipped, err := s.uploadBisect(context.Background(), endpoint, "dcr-1", "Custom-Test", b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("uploadBisect() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, record := range rejected {
+				got = append(got, record.Log["n"])
+
+				var uploadErr *UploadError
+				if !errors.As(record.Err, &uploadErr) || uploadErr.Message != "invalid field "+record.Log["bad"] {
+					t.Errorf("log %s rejected with %v", record.Log["n"], record.Err)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantRejected) {
+				t.Errorf("rejected logs %v, want %v", got, tt.wantRejected)
+			}
+
+			if len(ingest.accepted) != tt.wantAccepted {
+				t.Errorf("accepted %d logs, want %d", len(ingest.accepted), tt.wantAccepted)
+			}
+			if len(unshipped) != tt.wantUnshipped {
+				t.Errorf("%d logs left unshipped, want %d", len(unshipped), tt.wantUnshipped)
+			}
+			if len(ingest.accepted)+len(rejected)+len(unshipped) != len(tt.logs) {
+				t.Errorf("accepted, rejected and unshipped logs do not add up to the %d logs", len(tt.logs))
+			}
+			if ingest.uploads != tt.wantUploads {
+				t.Errorf("made %d uploads, want %d", ingest.uploads, tt.wantUploads)
+			}
+		})
+	}
+}
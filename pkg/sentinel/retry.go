@@ -0,0 +1,165 @@
+package sentinel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultUploadAttempts is the number of times a batch is uploaded before giving up.
+	DefaultUploadAttempts = 5
+
+	initialBackoff = time.Second
+	maxBackoff     = time.Minute
+)
+
+// UploadError is returned when the Logs Ingestion API answers a batch upload with an error status.
+type UploadError struct {
+	StatusCode int
+	ErrorCode  string
+	Message    string
+	// Attempts is the number of uploads made before giving up.
+	Attempts int
+
+	retryAfter time.Duration
+}
+
+func (e *UploadError) Error() string {
+	msg := fmt.Sprintf("logs ingestion api returned status %d", e.StatusCode)
+	if e.ErrorCode != "" {
+		msg += " (" + e.ErrorCode + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if hint := e.Hint(); hint != "" {
+		msg += ", " + hint
+	}
+
+	return msg
+}
+
+// Retriable reports whether uploading the same batch again may succeed.
+func (e *UploadError) Retriable() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// Hint explains the likely cause of the non-retriable errors.
+func (e *UploadError) Hint() string {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return "the records do not match the stream declaration of the data collection rule"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "the identity needs the Monitoring Metrics Publisher role on the data collection rule"
+	case http.StatusNotFound:
+		return "the data collection rule or stream does not exist"
+	case http.StatusRequestEntityTooLarge:
+		return "lower the max batch bytes"
+	}
+
+	return ""
+}
+
+// newUploadError converts an azcore response error, other errors such as timeouts are returned as is.
+func newUploadError(err error) error {
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) {
+		return err
+	}
+
+	uploadErr := &UploadError{
+		StatusCode: respErr.StatusCode,
+		ErrorCode:  respErr.ErrorCode,
+	}
+
+	if respErr.RawResponse != nil {
+		uploadErr.retryAfter = retryAfter(respErr.RawResponse.Header)
+
+		if body, err := runtime.Payload(respErr.RawResponse); err == nil {
+			var payload struct {
+				Error struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if json.Unmarshal(body, &payload) == nil {
+				uploadErr.Message = payload.Error.Message
+			}
+		}
+	}
+
+	return uploadErr
+}
+
+// retryAfter returns the delay requested by the service, zero when there is none.
+func retryAfter(header http.Header) time.Duration {
+	for _, name := range []string{"retry-after-ms", "x-ms-retry-after-ms"} {
+		if ms, err := strconv.Atoi(header.Get(name)); err == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+// backoff returns the delay before the next attempt, the requested delay wins over exponential backoff.
+func backoff(attempt int, err error) time.Duration {
+	var uploadErr *UploadError
+	if errors.As(err, &uploadErr) && uploadErr.retryAfter > 0 {
+		return uploadErr.retryAfter
+	}
+
+	delay := initialBackoff << (attempt - 1)
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+
+	// jitter keeps concurrent workers from retrying in lockstep
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retriable reports whether a failed upload should be attempted again.
+func retriable(err error) bool {
+	var uploadErr *UploadError
+	if errors.As(err, &uploadErr) {
+		return uploadErr.Retriable()
+	}
+
+	// a credential that cannot get a token will not get one on the next attempt either
+	var authErr *azidentity.AuthenticationFailedError
+	if errors.As(err, &authErr) {
+		return false
+	}
+
+	// transport errors and timeouts are transient
+	return true
+}
+
+// SetUploadAttempts changes the number of times a batch is uploaded before giving up, zero keeps the default.
+func (s *Sentinel) SetUploadAttempts(attempts int) {
+	s.uploadAttempts = DefaultUploadAttempts
+	if attempts > 0 {
+		s.uploadAttempts = attempts
+	}
+}
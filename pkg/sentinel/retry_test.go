@@ -0,0 +1,142 @@
+package sentinel
+
+import (
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    time.Duration
+	}{
+		{
+			name: "none",
+			want: 0,
+		},
+		{
+			name:    "retry-after-ms",
+			headers: map[string]string{"retry-after-ms": "1500"},
+			want:    1500 * time.Millisecond,
+		},
+		{
+			name:    "x-ms-retry-after-ms",
+			headers: map[string]string{"x-ms-retry-after-ms": "250"},
+			want:    250 * time.Millisecond,
+		},
+		{
+			name:    "seconds",
+			headers: map[string]string{"Retry-After": "7"},
+			want:    7 * time.Second,
+		},
+		{
+			name:    "milliseconds win over seconds",
+			headers: map[string]string{"Retry-After": "7", "retry-after-ms": "100"},
+			want:    100 * time.Millisecond,
+		},
+		{
+			name:    "invalid milliseconds fall back to seconds",
+			headers: map[string]string{"Retry-After": "2", "retry-after-ms": "soon"},
+			want:    2 * time.Second,
+		},
+		{
+			name:    "zero",
+			headers: map[string]string{"Retry-After": "0"},
+			want:    0,
+		},
+		{
+			name:    "negative",
+			headers: map[string]string{"Retry-After": "-3", "retry-after-ms": "-3"},
+			want:    0,
+		},
+		{
+			name:    "garbage",
+			headers: map[string]string{"Retry-After": "later"},
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for name, value := range tt.headers {
+				header.Set(name, value)
+			}
+
+			if got := retryAfter(header); got != tt.want {
+				t.Fatalf("retryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfterDate(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+
+	// the date has a one second resolution
+	if got := retryAfter(header); got < 58*time.Second || got > time.Minute {
+		t.Fatalf("retryAfter() = %v, want about a minute", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 1; attempt <= 10; attempt++ {
+		delay := initialBackoff << (attempt - 1)
+		if delay > maxBackoff {
+			delay = maxBackoff
+		}
+
+		got := backoff(attempt, fmt.Errorf("connection reset"))
+		if got < delay/2 || got > delay {
+			t.Errorf("backoff(%d) = %v, want between %v and %v", attempt, got, delay/2, delay)
+		}
+	}
+
+	// large attempts must not overflow into a negative shift
+	if got := backoff(100, fmt.Errorf("connection reset")); got < maxBackoff/2 || got > maxBackoff {
+		t.Errorf("backoff(100) = %v, want at most %v", got, maxBackoff)
+	}
+
+	requested := &UploadError{StatusCode: http.StatusTooManyRequests, retryAfter: 42 * time.Second}
+	if got := backoff(1, fmt.Errorf("could not upload logs: %w", requested)); got != 42*time.Second {
+		t.Errorf("backoff() = %v, want the requested 42s", got)
+	}
+}
+
+func TestNewUploadError(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodPost, "https://dce.example.com/dataCollectionRules/dcr-1/streams/Custom-Test", nil)
+	response := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"3"}, "Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"error":{"code":"TooManyRequests","message":"slow down"}}`)),
+		Request:    request,
+	}
+
+	err := newUploadError(runtime.NewResponseError(response))
+
+	uploadErr, ok := err.(*UploadError)
+	if !ok {
+		t.Fatalf("got %T, want *UploadError", err)
+	}
+
+	if uploadErr.StatusCode != http.StatusTooManyRequests || !uploadErr.Retriable() {
+		t.Errorf("unexpected status: %d", uploadErr.StatusCode)
+	}
+	if uploadErr.Message != "slow down" {
+		t.Errorf("message = %q, want %q", uploadErr.Message, "slow down")
+	}
+	if uploadErr.retryAfter != 3*time.Second {
+		t.Errorf("retry after = %v, want 3s", uploadErr.retryAfter)
+	}
+
+	if other := fmt.Errorf("dial tcp: timeout"); newUploadError(other) != other {
+		t.Errorf("transport errors must be returned as is")
+	}
+}
@@ -2,33 +2,97 @@ package sentinel
 
 import (
 	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/monitor/ingestion/azlogs"
 	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/spool"
 	"net/http"
+	"os"
+	"sync"
 )
 
+const (
+	CredentialClientSecret      = "client-secret"
+	CredentialClientCertificate = "client-certificate"
+	CredentialManagedIdentity   = "managed-identity"
+	CredentialWorkloadIdentity  = "workload-identity"
+	CredentialAzureCLI          = "azure-cli"
+	CredentialDefault           = "default"
+)
+
+// CredentialTypes lists the supported ways to authenticate to Azure.
+var CredentialTypes = []string{
+	CredentialClientSecret,
+	CredentialClientCertificate,
+	CredentialManagedIdentity,
+	CredentialWorkloadIdentity,
+	CredentialAzureCLI,
+	CredentialDefault,
+}
+
 type Credentials struct {
+	// Type selects the Azure credential, it defaults to a client secret.
+	Type string
+
 	TenantID       string
 	ClientID       string
 	ClientSecret   string
 	SubscriptionID string
+
+	CertificateFile     string
+	CertificatePassword string
+
+	// FederatedTokenFile is the workload identity token, AKS provides it through AZURE_FEDERATED_TOKEN_FILE.
+	FederatedTokenFile string
+
+	// Cloud is the Azure cloud to authenticate and ingest in, the public cloud when left empty.
+	Cloud cloud.Configuration
 }
 
 type Sentinel struct {
 	creds  Credentials
 	logger *logrus.Logger
 
-	azCreds    *azidentity.ClientSecretCredential
+	azCreds    azcore.TokenCredential
 	httpClient *http.Client
+
+	clientsMu sync.Mutex
+	clients   map[string]*azlogs.Client
+	arm       *arm.Client
+
+	// armEndpoint replaces the Azure Resource Manager of the cloud, see UseARMStandIn
+	armEndpoint string
+
+	maxBatchBytes   int
+	maxBatchRecords int
+	uploadWorkers   int
+	uploadAttempts  int
+
+	spool         *spool.Spool
+	flushAttempts int
 }
 
 func New(logger *logrus.Logger, creds Credentials) (*Sentinel, error) {
+	if len(creds.Cloud.Services) == 0 {
+		creds.Cloud, _ = CloudConfiguration(CloudPublic, CustomCloud{})
+	}
+
 	sentinel := Sentinel{
 		creds:  creds,
 		logger: logger,
+
+		clients: map[string]*azlogs.Client{},
+
+		maxBatchBytes:  DefaultBatchBytes,
+		uploadWorkers:  DefaultUploadWorkers,
+		uploadAttempts: DefaultUploadAttempts,
+		flushAttempts:  DefaultFlushAttempts,
 	}
 
-	azCreds, err := azidentity.NewClientSecretCredential(creds.TenantID, creds.ClientID, creds.ClientSecret, nil)
+	azCreds, err := newCredential(creds)
 	if err != nil {
 		return nil, fmt.Errorf("could not authenticate to MS Sentinel: %v", err)
 	}
@@ -37,3 +101,60 @@ func New(logger *logrus.Logger, creds Credentials) (*Sentinel, error) {
 
 	return &sentinel, nil
 }
+
+// newCredential builds the Azure credential selected by creds.Type.
+func newCredential(creds Credentials) (azcore.TokenCredential, error) {
+	clientOptions := azcore.ClientOptions{Cloud: creds.Cloud}
+
+	switch creds.Type {
+	case "", CredentialClientSecret:
+		return azidentity.NewClientSecretCredential(creds.TenantID, creds.ClientID, creds.ClientSecret,
+			&azidentity.ClientSecretCredentialOptions{ClientOptions: clientOptions})
+
+	case CredentialClientCertificate:
+		certData, err := os.ReadFile(creds.CertificateFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read certificate: %v", err)
+		}
+
+		certs, key, err := azidentity.ParseCertificates(certData, []byte(creds.CertificatePassword))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse certificate: %v", err)
+		}
+
+		return azidentity.NewClientCertificateCredential(creds.TenantID, creds.ClientID, certs, key,
+			&azidentity.ClientCertificateCredentialOptions{ClientOptions: clientOptions})
+
+	case CredentialManagedIdentity:
+		opts := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+		// a client ID selects a user-assigned identity, otherwise the system-assigned identity is used
+		if creds.ClientID != "" {
+			opts.ID = azidentity.ClientID(creds.ClientID)
+		}
+
+		return azidentity.NewManagedIdentityCredential(opts)
+
+	case CredentialWorkloadIdentity:
+		// empty fields fall back to the AZURE_* variables injected by the workload identity webhook
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientOptions: clientOptions,
+			TenantID:      creds.TenantID,
+			ClientID:      creds.ClientID,
+			TokenFilePath: creds.FederatedTokenFile,
+		})
+
+	case CredentialAzureCLI:
+		// the Azure CLI talks to the cloud selected with `az cloud set`
+		return azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{
+			TenantID: creds.TenantID,
+		})
+
+	case CredentialDefault:
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			ClientOptions: clientOptions,
+			TenantID:      creds.TenantID,
+		})
+	}
+
+	return nil, fmt.Errorf("unknown credential type '%s'", creds.Type)
+}
@@ -0,0 +1,222 @@
+package sentinel
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gong2sentinel/pkg/spool"
+	"time"
+)
+
+// DefaultFlushAttempts is the number of flushes an entry may fail before it is parked.
+const DefaultFlushAttempts = 5
+
+// SetSpool makes SendLogs keep every batch on disk until it is uploaded,
+// batches that cannot be uploaded are moved to the dead-letter queue. Nil disables spooling.
+func (s *Sentinel) SetSpool(sp *spool.Spool) {
+	s.spool = sp
+}
+
+// SetFlushAttempts changes the number of flushes an entry may fail before it is parked, zero keeps the default.
+func (s *Sentinel) SetFlushAttempts(attempts int) {
+	s.flushAttempts = DefaultFlushAttempts
+	if attempts > 0 {
+		s.flushAttempts = attempts
+	}
+}
+
+func (s *Sentinel) spoolBatch(endpoint, ruleID, streamName string, b batch) (string, error) {
+	if s.spool == nil {
+		return "", nil
+	}
+
+	return s.spool.Write(spool.Entry{
+		Endpoint: endpoint,
+		RuleID:   ruleID,
+		Stream:   streamName,
+		Created:  time.Now(),
+		Logs:     b.logs,
+	})
+}
+
+// settleBatch removes a batch from the pending queue once it is uploaded. Its rejected logs are dead-lettered,
+// and so are the unshipped ones when the upload failed: the logs Azure accepted are not spooled again
+// as the next flush would duplicate them.
+func (s *Sentinel) settleBatch(id, endpoint, ruleID, streamName string, rejected []RejectedRecord,
+	unshipped []map[string]string, uploadErr error) error {
+	if s.spool == nil {
+		return nil
+	}
+
+	if err := s.rejectLogs(endpoint, ruleID, streamName, rejected); err != nil {
+		return err
+	}
+
+	if uploadErr != nil && len(unshipped) > 0 {
+		if _, err := s.spool.Reject(spool.Entry{
+			Endpoint: endpoint,
+			RuleID:   ruleID,
+			Stream:   streamName,
+			Created:  time.Now(),
+			Error:    uploadErr.Error(),
+			Logs:     unshipped,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// the batch was uploaded without being spooled
+	if id == "" {
+		return nil
+	}
+
+	return s.spool.Remove(spool.Pending, id)
+}
+
+// rejectLogs dead-letters logs that can never be uploaded as is, each in its own entry with its error,
+// and sets the SpoolID of the records.
+func (s *Sentinel) rejectLogs(endpoint, ruleID, streamName string, rejected []RejectedRecord) error {
+	if s.spool == nil {
+		return nil
+	}
+
+	for i, record := range rejected {
+		id, err := s.spool.Reject(spool.Entry{
+			Endpoint: endpoint,
+			RuleID:   ruleID,
+			Stream:   streamName,
+			Created:  time.Now(),
+			Error:    record.Err.Error(),
+			Logs:     []map[string]string{record.Log},
+		})
+		if err != nil {
+			return err
+		}
+		rejected[i].SpoolID = id
+	}
+
+	return nil
+}
+
+// Flush uploads the dead-lettered batches, and those left pending by an interrupted run, again.
+// An entry is only removed once its logs are shipped, or once the logs that failed again are spooled in a new
+// dead-letter entry counting the failed flush. Entries failing more than the flush attempts are parked instead,
+// so logs Azure keeps rejecting are not uploaded forever.
+func (s *Sentinel) Flush(ctx context.Context, l *logrus.Logger) error {
+	logger := l.WithField("module", "sentinel_spool")
+
+	if s.spool == nil {
+		return fmt.Errorf("no spool configured")
+	}
+
+	// both queues are listed upfront so entries failing again during the flush are not retried twice
+	queues := map[string][]string{}
+	for _, queue := range []string{spool.Pending, spool.DeadLetter} {
+		ids, err := s.spool.List(queue)
+		if err != nil {
+			return err
+		}
+		queues[queue] = ids
+	}
+
+	var total, failed, parked int
+	for _, queue := range []string{spool.Pending, spool.DeadLetter} {
+		for _, id := range queues[queue] {
+			total++
+			entryLogger := logger.WithField("queue", queue).WithField("entry", id)
+
+			entry, err := s.spool.Read(queue, id)
+			if err != nil {
+				entryLogger.WithError(err).Error("could not read spool entry")
+				failed++
+				continue
+			}
+
+			remaining, flushErr := s.flushEntry(ctx, entry)
+			if flushErr == nil {
+				if err := s.spool.Remove(queue, id); err != nil {
+					entryLogger.WithError(err).Error("could not remove flushed spool entry")
+				}
+
+				entryLogger.WithField("total", len(entry.Logs)).Info("flushed spool entry")
+				continue
+			}
+
+			failed++
+			entry.Attempts++
+			entry.Error = flushErr.Error()
+			entry.Logs = remaining
+			entryLogger = entryLogger.WithField("attempts", entry.Attempts).WithField("total", len(remaining))
+
+			// the entry is only removed once the logs that failed again are spooled
+			park := entry.Attempts >= s.flushAttempts
+			respool := s.spool.Reject
+			if park {
+				respool = s.spool.Park
+			}
+			if _, err := respool(entry); err != nil {
+				entryLogger.WithError(err).Error("could not spool the logs that failed again, keeping the entry")
+				continue
+			}
+
+			if err := s.spool.Remove(queue, id); err != nil {
+				entryLogger.WithError(err).Error("could not remove flushed spool entry")
+			}
+
+			if park {
+				parked++
+				entryLogger.WithError(flushErr).Error("parked spool entry that failed too many flushes")
+				continue
+			}
+
+			entryLogger.WithError(flushErr).Error("could not flush spool entry")
+		}
+	}
+
+	if parked > 0 {
+		logger.WithField("parked", parked).Warn("parked spool entries that failed too many flushes")
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("could not flush %d of %d spool entries", failed, total)
+	}
+
+	logger.WithField("total", total).Info("flushed spool")
+
+	return nil
+}
+
+// flushEntry uploads the logs of a spool entry, it returns the logs that were neither shipped nor accepted
+// along with the first error. Rejected logs are returned with a *RejectedError when all others were shipped.
+func (s *Sentinel) flushEntry(ctx context.Context, entry spool.Entry) ([]map[string]string, error) {
+	batches, oversized, err := batchLogs(entry.Logs, s.maxBatchBytes, s.maxBatchRecords)
+	if err != nil {
+		return entry.Logs, fmt.Errorf("could not batch spooled logs: %v", err)
+	}
+
+	rejectedErr := &RejectedError{Stream: entry.Stream, Records: oversized}
+	var remaining []map[string]string
+	var firstErr error
+
+	for _, b := range batches {
+		rejected, unshipped, err := s.uploadBisect(ctx, entry.Endpoint, entry.RuleID, entry.Stream, b)
+		rejectedErr.Records = append(rejectedErr.Records, rejected...)
+		remaining = append(remaining, unshipped...)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, record := range rejectedErr.Records {
+		remaining = append(remaining, record.Log)
+	}
+
+	if firstErr != nil {
+		return remaining, firstErr
+	}
+	if len(rejectedErr.Records) > 0 {
+		return remaining, rejectedErr
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,268 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"gong2sentinel/pkg/spool"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// queueLogs returns the number of entries and logs in a spool queue.
+func queueLogs(t *testing.T, sp *spool.Spool, queue string) (int, int) {
+	t.Helper()
+
+	ids, err := sp.List(queue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logs int
+	for _, id := range ids {
+		entry, err := sp.Read(queue, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logs += len(entry.Logs)
+	}
+
+	return len(ids), logs
+}
+
+func TestSpoolLifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	ingest := &ingestServer{status: http.StatusServiceUnavailable}
+	s, endpoint := newIngestSentinel(t, ingest)
+
+	sp, err := spool.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetSpool(sp)
+	s.SetBatchLimits(0, 4)
+
+	// an outage dead-letters every batch and leaves nothing pending
+	if err := s.SendLogs(ctx, discardLogger(), endpoint, "dcr-1", "Custom-Test", numberedLogs(10, nil)); err == nil {
+		t.Fatal("SendLogs() succeeded during an outage")
+	}
+
+	if entries, _ := queueLogs(t, sp, spool.Pending); entries != 0 {
+		t.Fatalf("%d entries left pending, want 0", entries)
+	}
+	if entries, logs := queueLogs(t, sp, spool.DeadLetter); entries != 3 || logs != 10 {
+		t.Fatalf("dead-lettered %d entries with %d logs, want 3 with 10", entries, logs)
+	}
+
+	// a flush during the outage tries every entry once and dead-letters them again
+	ingest.uploads = 0
+	if err := s.Flush(ctx, discardLogger()); err == nil {
+		t.Fatal("Flush() succeeded during an outage")
+	}
+	if ingest.uploads != 3 {
+		t.Fatalf("flush made %d uploads, want one per entry", ingest.uploads)
+	}
+	if entries, logs := queueLogs(t, sp, spool.DeadLetter); entries != 3 || logs != 10 {
+		t.Fatalf("dead-lettered %d entries with %d logs after the flush, want 3 with 10", entries, logs)
+	}
+
+	// a batch left pending by an interrupted run is flushed along with the dead-lettered ones
+	if _, err := sp.Write(spool.Entry{Endpoint: endpoint, RuleID: "dcr-1", Stream: "Custom-Test", Created: time.Now(),
+		Logs: numberedLogs(2, nil)}); err != nil {
+		t.Fatal(err)
+	}
+
+	ingest.status = 0
+	if err := s.Flush(ctx, discardLogger()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	for _, queue := range []string{spool.Pending, spool.DeadLetter} {
+		if entries, _ := queueLogs(t, sp, queue); entries != 0 {
+			t.Errorf("%d entries left in the %s queue, want 0", entries, queue)
+		}
+	}
+	if len(ingest.accepted) != 12 {
+		t.Errorf("accepted %d logs, want 12", len(ingest.accepted))
+	}
+}
+
+func TestSpoolKeepsOnlyUnshippedLogs(t *testing.T) {
+	ingest := &ingestServer{}
+	s, endpoint := newIngestSentinel(t, ingest)
+
+	sp, err := spool.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetSpool(sp)
+
+	// log 1 is rejected on its own, the half holding log 6 fails after the other half was shipped
+	logs := withFailure(numberedLogs(8, map[int]string{1: "a"}), 6)
+	if err := s.SendLogs(context.Background(), discardLogger(), endpoint, "dcr-1", "Custom-Test", logs); err == nil {
+		t.Fatal("SendLogs() succeeded with a failing half")
+	}
+
+	if entries, _ := queueLogs(t, sp, spool.Pending); entries != 0 {
+		t.Fatalf("%d entries left pending, want 0", entries)
+	}
+
+	// the rejected log and the unshipped half, none of the 3 accepted logs
+	entries, spooled := queueLogs(t, sp, spool.DeadLetter)
+	if entries != 2 || spooled != 5 {
+		t.Fatalf("dead-lettered %d entries with %d logs, want 2 with 5", entries, spooled)
+	}
+	if len(ingest.accepted) != 3 {
+		t.Fatalf("accepted %d logs, want 3", len(ingest.accepted))
+	}
+}
+
+func TestRejectedLogsAreNotLogged(t *testing.T) {
+	ingest := &ingestServer{}
+	s, endpoint := newIngestSentinel(t, ingest)
+
+	sp, err := spool.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetSpool(sp)
+
+	logger, hook := test.NewNullLogger()
+
+	logs := numberedLogs(4, map[int]string{2: "secret"})
+	var rejectedErr *RejectedError
+	if err := s.SendLogs(context.Background(), logger, endpoint, "dcr-1", "Custom-Test", logs); !errors.As(err, &rejectedErr) {
+		t.Fatalf("SendLogs() error = %v, want a *RejectedError", err)
+	}
+
+	var logged int
+	for _, entry := range hook.AllEntries() {
+		if entry.Message != "log rejected by Sentinel" {
+			continue
+		}
+		logged++
+
+		if _, ok := entry.Data["log"]; ok {
+			t.Error("the rejected log is logged")
+		}
+		for _, field := range []string{"stream", "bytes", "entry", logrus.ErrorKey} {
+			if _, ok := entry.Data[field]; !ok {
+				t.Errorf("no %s logged", field)
+			}
+		}
+
+		if _, err := sp.Read(spool.DeadLetter, entry.Data["entry"].(string)); err != nil {
+			t.Errorf("logged entry is not dead-lettered: %v", err)
+		}
+	}
+	if logged != 1 {
+		t.Errorf("logged %d rejected logs, want 1", logged)
+	}
+}
+
+func TestFlushParksRepeatedlyFailingEntries(t *testing.T) {
+	ctx := context.Background()
+
+	ingest := &ingestServer{}
+	s, endpoint := newIngestSentinel(t, ingest)
+	s.SetFlushAttempts(2)
+
+	sp, err := spool.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetSpool(sp)
+
+	if _, err := sp.Reject(spool.Entry{Endpoint: endpoint, RuleID: "dcr-1", Stream: "Custom-Test", Created: time.Now(),
+		Logs: numberedLogs(4, map[int]string{2: "a"})}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		wantAccepted int
+		wantDead     int
+		wantParked   int
+		wantAttempts int
+		wantUploads  int
+		wantErr      bool
+	}{
+		// the good logs ship, the rejected one is dead-lettered again counting the failed flush
+		{name: "first flush", wantAccepted: 3, wantDead: 1, wantAttempts: 1, wantUploads: 5, wantErr: true},
+		{name: "second flush parks the entry", wantAccepted: 3, wantParked: 1, wantAttempts: 2, wantUploads: 1, wantErr: true},
+		{name: "parked entries are not flushed", wantAccepted: 3, wantParked: 1, wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		ingest.uploads = 0
+		err := s.Flush(ctx, discardLogger())
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Flush() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+
+		if len(ingest.accepted) != tt.wantAccepted {
+			t.Errorf("%s: accepted %d logs, want %d", tt.name, len(ingest.accepted), tt.wantAccepted)
+		}
+		if ingest.uploads != tt.wantUploads {
+			t.Errorf("%s: made %d uploads, want %d", tt.name, ingest.uploads, tt.wantUploads)
+		}
+		if entries, logs := queueLogs(t, sp, spool.DeadLetter); entries != tt.wantDead || logs != tt.wantDead {
+			t.Errorf("%s: dead-lettered %d entries with %d logs, want %d", tt.name, entries, logs, tt.wantDead)
+		}
+		if entries, _ := queueLogs(t, sp, spool.Parked); entries != tt.wantParked {
+			t.Errorf("%s: parked %d entries, want %d", tt.name, entries, tt.wantParked)
+		}
+
+		for _, queue := range []string{spool.DeadLetter, spool.Parked} {
+			ids, _ := sp.List(queue)
+			for _, id := range ids {
+				entry, err := sp.Read(queue, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if entry.Attempts != tt.wantAttempts || entry.Logs[0]["n"] != "2" {
+					t.Errorf("%s: %s entry has %d attempts with log %s, want %d with log 2",
+						tt.name, queue, entry.Attempts, entry.Logs[0]["n"], tt.wantAttempts)
+				}
+			}
+		}
+	}
+}
+
+func TestFlushKeepsEntriesThatCannotBeSpooledAgain(t *testing.T) {
+	ingest := &ingestServer{status: http.StatusServiceUnavailable}
+	s, endpoint := newIngestSentinel(t, ingest)
+
+	dir := t.TempDir()
+	sp, err := spool.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetSpool(sp)
+
+	if _, err := sp.Write(spool.Entry{Endpoint: endpoint, RuleID: "dcr-1", Stream: "Custom-Test", Created: time.Now(),
+		Logs: numberedLogs(2, nil)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the dead-letter queue cannot be written once it is replaced by a file
+	if err := os.RemoveAll(filepath.Join(dir, spool.DeadLetter)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, spool.DeadLetter), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Flush(context.Background(), discardLogger()); err == nil {
+		t.Fatal("Flush() succeeded during an outage")
+	}
+
+	if entries, logs := queueLogs(t, sp, spool.Pending); entries != 1 || logs != 2 {
+		t.Errorf("%d entries with %d logs left pending, want the entry with its 2 logs", entries, logs)
+	}
+}
@@ -0,0 +1,141 @@
+package spool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Pending holds batches that are being uploaded, or were when the process died.
+	Pending = "pending"
+	// DeadLetter holds batches that could not be uploaded and wait for a flush.
+	DeadLetter = "dead-letter"
+	// Parked holds batches that failed too many flushes, they are left for an operator to inspect.
+	Parked = "parked"
+)
+
+// Entry is a batch of logs with the destination it should be uploaded to.
+type Entry struct {
+	Endpoint string    `json:"endpoint"`
+	RuleID   string    `json:"ruleId"`
+	Stream   string    `json:"stream"`
+	Created  time.Time `json:"created"`
+	Error    string    `json:"error,omitempty"`
+	// Attempts is the number of flushes the logs failed
+	Attempts int                 `json:"attempts,omitempty"`
+	Logs     []map[string]string `json:"logs"`
+}
+
+// Spool keeps batches on disk until they are uploaded so they survive failed runs.
+type Spool struct {
+	dir string
+}
+
+func New(dir string) (*Spool, error) {
+	for _, queue := range []string{Pending, DeadLetter, Parked} {
+		if err := os.MkdirAll(filepath.Join(dir, queue), 0o700); err != nil {
+			return nil, fmt.Errorf("could not create spool directory '%s': %v", dir, err)
+		}
+	}
+
+	return &Spool{dir: dir}, nil
+}
+
+func (s *Spool) path(queue, id string) string {
+	return filepath.Join(s.dir, queue, id+".json")
+}
+
+// Write stores an entry in the pending queue and returns its ID.
+func (s *Spool) Write(entry Entry) (string, error) {
+	return s.write(Pending, entry)
+}
+
+func (s *Spool) write(queue string, entry Entry) (string, error) {
+	id := entry.Created.UTC().Format("20060102T150405") + "-" + entry.Stream + "-" + uuid.NewString()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("could not encode spool entry: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(s.dir, queue), id+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("could not create temporary spool file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("could not write spool entry '%s': %v", id, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("could not write spool entry '%s': %v", id, err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(queue, id)); err != nil {
+		return "", fmt.Errorf("could not store spool entry '%s': %v", id, err)
+	}
+
+	return id, nil
+}
+
+// Read decodes the entry with the given ID from a queue.
+func (s *Spool) Read(queue, id string) (Entry, error) {
+	var entry Entry
+
+	data, err := os.ReadFile(s.path(queue, id))
+	if err != nil {
+		return entry, fmt.Errorf("could not read spool entry '%s': %v", id, err)
+	}
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, fmt.Errorf("could not parse spool entry '%s': %v", id, err)
+	}
+
+	return entry, nil
+}
+
+// List returns the IDs of all entries in a queue, oldest first.
+func (s *Spool) List(queue string) ([]string, error) {
+	files, err := os.ReadDir(filepath.Join(s.dir, queue))
+	if err != nil {
+		return nil, fmt.Errorf("could not list spool queue '%s': %v", queue, err)
+	}
+
+	var ids []string
+	for _, file := range files {
+		if id, ok := strings.CutSuffix(file.Name(), ".json"); ok && !file.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// Remove deletes an entry from a queue, removing an entry that is already gone is not an error.
+func (s *Spool) Remove(queue, id string) error {
+	if err := os.Remove(s.path(queue, id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not remove spool entry '%s': %v", id, err)
+	}
+
+	return nil
+}
+
+// Park stores logs that failed too many flushes in the parked queue and returns the entry ID.
+func (s *Spool) Park(entry Entry) (string, error) {
+	return s.write(Parked, entry)
+}
+
+// Reject stores logs that could not be uploaded in the dead-letter queue and returns the entry ID, the entry records why.
+func (s *Spool) Reject(entry Entry) (string, error) {
+	return s.write(DeadLetter, entry)
+}
@@ -0,0 +1,167 @@
+package synthetic
+
+import (
+	"fmt"
+	"gong2sentinel/pkg/gong/auditing"
+	"time"
+)
+
+func init() {
+	register(Scenario{
+		Name:        "impersonation-session",
+		Description: "an admin impersonates another user and browses and changes their settings",
+		generate:    impersonationSession,
+	})
+	register(Scenario{
+		Name:        "mass-call-playback",
+		Description: "a single user plays back a large number of distinct calls within one hour",
+		generate:    massCallPlayback,
+	})
+	register(Scenario{
+		Name:        "external-share-new-country",
+		Description: "an externally shared call is opened and played from an unusual country",
+		generate:    externalShareNewCountry,
+	})
+	register(Scenario{
+		Name:        "admin-permission-change",
+		Description: "a user is moved to an administrator permission profile",
+		generate:    adminPermissionChange,
+	})
+}
+
+func impersonationSession(opts Options, now time.Time) []entry {
+	var entries []entry
+
+	endpoints := []string{"/ajax/home", "/ajax/calls/search", "/ajax/settings/personal", "/ajax/settings/personal/update"}
+	start := now.Add(-time.Minute * 30)
+
+	for i, endpoint := range endpoints {
+		e := auditing.UserActivityLogEntry{
+			UserID:                   opts.TargetID,
+			UserEmailAddress:         opts.TargetEmail,
+			UserFullName:             nameMarker + opts.TargetName,
+			EventTime:                start.Add(time.Duration(i) * time.Minute * 5),
+			ImpersonatorUserID:       opts.ActorID,
+			ImpersonatorEmailAddress: opts.ActorEmail,
+			ImpersonatorFullName:     nameMarker + opts.ActorName,
+			ImpersonatorCompanyID:    "synthetic",
+		}
+		e.LogRecord.HTTPRequest.ClientIP = opts.InternalIP
+		e.LogRecord.HTTPRequest.EndpointURI = endpoint
+		e.LogRecord.HTTPRequest.ReferrerURI = "https://app.gong.io/home"
+		e.LogRecord.HTTPRequest.Verb = "GET"
+
+		if i == len(endpoints)-1 {
+			e.LogRecord.HTTPRequest.Verb = "POST"
+			e.LogRecord.TableChanges = []auditing.TableChange{
+				tableChange("user_settings", e.EventTime, opts.TargetID, "email_import_enabled", "true", "false"),
+			}
+		}
+
+		entries = append(entries, entry{logType: "UserActivityLog", value: e})
+	}
+
+	return entries
+}
+
+func massCallPlayback(opts Options, now time.Time) []entry {
+	var entries []entry
+
+	start := now.Add(-time.Hour)
+	step := time.Hour / time.Duration(opts.Calls+1)
+
+	for i := 0; i < opts.Calls; i++ {
+		eventTime := start.Add(time.Duration(i) * step)
+
+		e := auditing.UserCallPlayEntry{
+			UserID:           opts.ActorID,
+			UserEmailAddress: opts.ActorEmail,
+			UserFullName:     nameMarker + opts.ActorName,
+			EventTime:        eventTime,
+		}
+		e.LogRecord.CallID = syntheticCallID(i)
+		e.LogRecord.VideoPlayerInstanceID = fmt.Sprintf("synthetic-player-%d", i)
+		e.LogRecord.PlaySpeed = 2
+		e.LogRecord.Device = "WEB"
+		e.LogRecord.StartTime = 0
+		e.LogRecord.EndTime = 30
+		e.LogRecord.EventTimeOnDevice = eventTime
+
+		entries = append(entries, entry{logType: "UserCallPlay", value: e})
+	}
+
+	return entries
+}
+
+func externalShareNewCountry(opts Options, now time.Time) []entry {
+	callID := syntheticCallID(0)
+	sharingID := "9000000000000000100"
+	eventTime := now.Add(-time.Minute * 10)
+
+	access := auditing.ExternallySharedCallAccessEntry{
+		UserEmailAddress: opts.ActorEmail,
+		UserFullName:     nameMarker + opts.ActorName,
+		EventTime:        eventTime,
+	}
+	access.LogRecord.CallID = callID
+	access.LogRecord.TimeBasedSecureSharingID = sharingID
+	access.LogRecord.PageViewerIP = opts.ExternalIP
+
+	play := auditing.ExternallySharedCallPlayEntry{
+		UserEmailAddress: opts.ActorEmail,
+		UserFullName:     nameMarker + opts.ActorName,
+		EventTime:        eventTime.Add(time.Minute),
+	}
+	play.LogRecord.CallID = callID
+	play.LogRecord.TimeBasedSecureSharingID = sharingID
+	play.LogRecord.VideoPlayerInstanceID = "synthetic-external-player"
+	play.LogRecord.SequenceNum = "0"
+	play.LogRecord.PlaySpeed = 1
+	play.LogRecord.Device = "WEB"
+	play.LogRecord.EndTime = 600
+	play.LogRecord.EventTimeOnDevice = play.EventTime
+
+	return []entry{
+		{logType: "ExternallySharedCallAccess", value: access},
+		{logType: "ExternallySharedCallPlay", value: play},
+	}
+}
+
+func adminPermissionChange(opts Options, now time.Time) []entry {
+	e := auditing.UserActivityLogEntry{
+		UserID:           opts.ActorID,
+		UserEmailAddress: opts.ActorEmail,
+		UserFullName:     nameMarker + opts.ActorName,
+		EventTime:        now.Add(-time.Minute * 5),
+	}
+	e.LogRecord.HTTPRequest.ClientIP = opts.InternalIP
+	e.LogRecord.HTTPRequest.EndpointURI = "/ajax/settings/users/update-permission-profile"
+	e.LogRecord.HTTPRequest.ReferrerURI = "https://app.gong.io/settings/users"
+	e.LogRecord.HTTPRequest.Verb = "POST"
+	e.LogRecord.TableChanges = []auditing.TableChange{
+		tableChange("user_permission_profiles", e.EventTime, opts.TargetID, "permission_profile", "Sales Rep", "Administrator"),
+	}
+
+	return []entry{{logType: "UserActivityLog", value: e}}
+}
+
+func tableChange(table string, at time.Time, userID, column, oldValue, newValue string) auditing.TableChange {
+	return auditing.TableChange{
+		PreSnapshotTimestamp:  at.Add(-time.Second),
+		PostSnapshotTimestamp: at,
+		TableName:             table,
+		RowChanges: []auditing.RowChange{{
+			PrimaryKeyColumns: []auditing.PrimaryKeyColumn{{ColumnName: "user_id", ColumnValue: userID}},
+			ColumnChanges: []auditing.ColumnChange{{
+				ColumnName: column,
+				Operation:  "UPDATE",
+				OldValue:   oldValue,
+				NewValue:   newValue,
+			}},
+		}},
+	}
+}
+
+func syntheticCallID(i int) string {
+	return fmt.Sprintf("9%018d", i)
+}
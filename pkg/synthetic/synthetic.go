@@ -0,0 +1,125 @@
+package synthetic
+
+import (
+	"fmt"
+	"gong2sentinel/pkg/gong/auditing"
+	"gong2sentinel/pkg/schema"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	iso8601Format = "2006-01-02T15:04:05Z"
+
+	// nameMarker is prepended to every generated user name so synthetic events stand out in Sentinel.
+	nameMarker = "[SYNTHETIC] "
+)
+
+// Columns are added to every synthetic record so it cannot be mistaken for real activity.
+type Columns struct {
+	Synthetic      bool   `json:"synthetic"`
+	Scenario       string `json:"scenario"`
+	SyntheticRunID string `json:"syntheticRunId"`
+}
+
+// Options tweaks the actors and volumes used by the scenarios.
+type Options struct {
+	// RunID is attached to every record so a test run can be isolated in KQL.
+	RunID string
+
+	ActorID    string
+	ActorEmail string
+	ActorName  string
+
+	TargetID    string
+	TargetEmail string
+	TargetName  string
+
+	// ExternalIP is the address used by external viewers, pick one that geolocates to an unusual country.
+	ExternalIP string
+	// InternalIP is the address used by regular user activity.
+	InternalIP string
+
+	// Calls is the number of distinct calls played in the mass playback scenario.
+	Calls int
+}
+
+// DefaultOptions returns options that only use reserved example domains and documentation IP ranges.
+func DefaultOptions() Options {
+	return Options{
+		RunID:       strconv.FormatInt(time.Now().Unix(), 10),
+		ActorID:     "9000000000000000001",
+		ActorEmail:  "mallory@synthetic.example.com",
+		ActorName:   "Mallory Tester",
+		TargetID:    "9000000000000000002",
+		TargetEmail: "victim@synthetic.example.com",
+		TargetName:  "Victor Tester",
+		ExternalIP:  "198.51.100.23",
+		InternalIP:  "192.0.2.10",
+		Calls:       100,
+	}
+}
+
+type entry struct {
+	logType string
+	value   interface{}
+}
+
+// Scenario crafts the Gong audit log entries for a single attack pattern.
+type Scenario struct {
+	Name        string
+	Description string
+
+	generate func(opts Options, now time.Time) []entry
+}
+
+var scenarios = map[string]Scenario{}
+
+func register(s Scenario) {
+	scenarios[s.Name] = s
+}
+
+// Scenarios returns all known scenarios sorted by name.
+func Scenarios() []Scenario {
+	var all []Scenario
+	for _, s := range scenarios {
+		all = append(all, s)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+
+	return all
+}
+
+// Generate returns the records for the named scenario, tagged as synthetic.
+func Generate(name string, opts Options, now time.Time) ([]map[string]string, error) {
+	scenario, ok := scenarios[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scenario: %s", name)
+	}
+	if opts.Calls < 1 {
+		return nil, fmt.Errorf("the number of calls must be positive, got %d", opts.Calls)
+	}
+
+	now = now.UTC().Truncate(time.Second)
+	timeGenerated := now.Format(iso8601Format)
+
+	var records []map[string]string
+	for _, e := range scenario.generate(opts, now) {
+		record, err := auditing.NewRecord(timeGenerated, e.logType, e.value)
+		if err != nil {
+			return nil, fmt.Errorf("could not create record for scenario %s: %v", name, err)
+		}
+
+		if err := schema.Merge(record, Columns{Synthetic: true, Scenario: name, SyntheticRunID: opts.RunID}); err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,82 @@
+package synthetic
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	opts := DefaultOptions()
+	opts.RunID = "run-1"
+	opts.Calls = 7
+
+	tests := []struct {
+		scenario     string
+		wantLogTypes []string
+	}{
+		{scenario: "impersonation-session", wantLogTypes: []string{"UserActivityLog", "UserActivityLog", "UserActivityLog", "UserActivityLog"}},
+		{scenario: "mass-call-playback", wantLogTypes: []string{"UserCallPlay", "UserCallPlay", "UserCallPlay", "UserCallPlay", "UserCallPlay", "UserCallPlay", "UserCallPlay"}},
+		{scenario: "external-share-new-country", wantLogTypes: []string{"ExternallySharedCallAccess", "ExternallySharedCallPlay"}},
+		{scenario: "admin-permission-change", wantLogTypes: []string{"UserActivityLog"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			records, err := Generate(tt.scenario, opts, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != len(tt.wantLogTypes) {
+				t.Fatalf("got %d records, want %d", len(records), len(tt.wantLogTypes))
+			}
+
+			for i, record := range records {
+				if record["synthetic"] != "true" || record["scenario"] != tt.scenario || record["syntheticRunId"] != "run-1" {
+					t.Errorf("record %d is not tagged as synthetic: %v", i, record)
+				}
+				if record["TimeGenerated"] != "2024-05-01T12:00:00Z" {
+					t.Errorf("record %d: got TimeGenerated %s", i, record["TimeGenerated"])
+				}
+				if record["logType"] != tt.wantLogTypes[i] {
+					t.Errorf("record %d: got log type %s, want %s", i, record["logType"], tt.wantLogTypes[i])
+				}
+
+				var logEntry struct {
+					EventTime time.Time `json:"eventTime"`
+				}
+				if err := json.Unmarshal([]byte(record["logEntry"]), &logEntry); err != nil {
+					t.Fatalf("record %d: %v", i, err)
+				}
+				if logEntry.EventTime.After(now) || logEntry.EventTime.Before(now.Add(-time.Hour)) {
+					t.Errorf("record %d: event time %s is outside the hour before now", i, logEntry.EventTime)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		calls    int
+	}{
+		{name: "unknown scenario", scenario: "nope", calls: 1},
+		{name: "no calls", scenario: "mass-call-playback", calls: 0},
+		{name: "negative calls", scenario: "mass-call-playback", calls: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Calls = tt.calls
+
+			if _, err := Generate(tt.scenario, opts, time.Now()); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
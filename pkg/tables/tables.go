@@ -0,0 +1,92 @@
+package tables
+
+import (
+	"gong2sentinel/pkg/gong"
+	"gong2sentinel/pkg/gong/auditing"
+	"gong2sentinel/pkg/gong/calls"
+	"gong2sentinel/pkg/gong/crm"
+	"gong2sentinel/pkg/gong/library"
+	"gong2sentinel/pkg/gong/permissions"
+	"gong2sentinel/pkg/gong/privacy"
+	"gong2sentinel/pkg/gong/snapshot"
+	"gong2sentinel/pkg/gong/stats"
+	"gong2sentinel/pkg/gong/users"
+	"gong2sentinel/pkg/gong/webhook"
+	"gong2sentinel/pkg/gong/workspaces"
+	"gong2sentinel/pkg/schema"
+	"gong2sentinel/pkg/synthetic"
+)
+
+// Run holds the columns added to every record of a collector run.
+type Run struct {
+	RunID string `json:"runId"`
+}
+
+var (
+	// source columns are added by gong.Source.Annotate to trace a record back to its API call.
+	source = gong.Source{}
+	run    = Run{}
+	// workspace columns are added to records with a workspaceId column.
+	workspace = workspaces.Enrichment{}
+)
+
+var (
+	AuditLogs = schema.NewTable("GongAuditLogs", "Gong audit log entries",
+		auditing.Record{}, workspace, source, run)
+
+	CallUserAccess = schema.NewTable("GongCallUserAccess", "Users with access to Gong calls",
+		calls.UserAccessRecord{}, workspace, source, run)
+
+	Users = schema.NewTable("GongUsers", "Snapshot of all Gong users",
+		users.Record{}, source, run)
+
+	UserSettings = schema.NewTable("GongUserSettings", "Changes of Gong user settings",
+		users.SettingsChangeRecord{}, source, run)
+
+	PermissionProfiles = schema.NewTable("GongPermissionProfiles", "Gong permission profile changes and assignments",
+		permissions.Record{}, workspace, source, run)
+
+	Workspaces = schema.NewTable("GongWorkspaces", "Gong workspaces",
+		workspaces.Record{}, source, run)
+
+	Settings = schema.NewTable("GongSettings", "Gong tracker and scorecard changes",
+		snapshot.Record{}, workspace, source, run)
+
+	CRMIntegrations = schema.NewTable("GongCRMIntegrations", "Gong CRM integration changes",
+		snapshot.Record{}, crm.OwnerColumns{}, workspace, source, run)
+
+	Calls = schema.NewTable("GongCalls", "Gong calls with their participants",
+		calls.Record{}, workspace, source, run)
+
+	LibraryFolders = schema.NewTable("GongLibraryFolders", "Gong Library folders and their calls",
+		library.Record{}, workspace, source, run)
+
+	UserActivity = schema.NewTable("GongUserActivity", "Daily Gong activity per user",
+		stats.Record{}, source, run)
+
+	Synthetic = schema.NewTable("GongSynthetic", "Synthetic Gong audit events for analytics rule testing",
+		auditing.Record{}, synthetic.Columns{})
+
+	Webhook = schema.NewTable("GongWebhook", "Calls pushed by Gong automation rule webhooks",
+		webhook.Record{})
+
+	PrivacyLookups = schema.NewTable("GongPrivacyLookups", "Data subject access request lookups",
+		privacy.Record{}, source)
+)
+
+// All lists the tables of all collectors.
+var All = []schema.Table{
+	AuditLogs, CallUserAccess, Users, UserSettings, PermissionProfiles, Workspaces, Settings,
+	CRMIntegrations, Calls, LibraryFolders, UserActivity, Synthetic, Webhook, PrivacyLookups,
+}
+
+// Find returns the table with the given name, with or without the _CL suffix.
+func Find(name string) (schema.Table, bool) {
+	for _, table := range All {
+		if table.Name == name || table.TableName() == name {
+			return table, true
+		}
+	}
+
+	return schema.Table{}, false
+}